@@ -1,7 +1,84 @@
 package main
 
-import "cart-api/internal/app"
+import (
+	"cart-api/internal/app"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage:
+  cart-api [serve] [--no-migrate]   start the HTTP server, applying pending migrations first
+  cart-api migrate up               apply all pending migrations
+  cart-api migrate down             roll back the latest migration
+  cart-api migrate status           list the migrations and whether they are applied
+  cart-api migrate to VERSION       migrate up or down to VERSION
+  cart-api seed FILE                load products and carts from a JSON fixtures file
+`
 
 func main() {
-	app.Run()
+	args := os.Args[1:]
+	command := "serve"
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		flags := flag.NewFlagSet("serve", flag.ExitOnError)
+		flags.Usage = printUsage
+		noMigrate := flags.Bool("no-migrate", false, "do not apply pending migrations on start")
+		flags.Parse(args)
+		if flags.NArg() > 0 {
+			fail("serve takes no arguments")
+		}
+		app.Serve(!*noMigrate)
+	case "migrate":
+		runMigrate(args)
+	case "seed":
+		if len(args) != 1 {
+			fail("seed takes the path of the fixtures file")
+		}
+		app.Seed(args[0])
+	case "help", "-h", "--help":
+		printUsage()
+	default:
+		fail(fmt.Sprintf("unknown command %q", command))
+	}
+}
+
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fail("migrate needs a command")
+	}
+	switch command := args[0]; command {
+	case app.MigrateUp, app.MigrateDown, app.MigrateStatus:
+		if len(args) != 1 {
+			fail(fmt.Sprintf("migrate %s takes no arguments", command))
+		}
+		app.Migrate(command, 0)
+	case app.MigrateTo:
+		if len(args) != 2 {
+			fail("migrate to takes the target version")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			fail(fmt.Sprintf("invalid version %q", args[1]))
+		}
+		app.Migrate(command, version)
+	default:
+		fail(fmt.Sprintf("unknown migrate command %q", command))
+	}
+}
+
+func printUsage() {
+	fmt.Fprint(os.Stderr, usage)
+}
+
+// fail reports a usage error and exits.
+func fail(msg string) {
+	fmt.Fprintf(os.Stderr, "cart-api: %s\n\n", msg)
+	printUsage()
+	os.Exit(2)
 }
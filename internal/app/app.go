@@ -1,71 +1,186 @@
 package app
 
 import (
+	"cart-api/internal/cache"
 	"cart-api/internal/config"
-	"cart-api/internal/db/postgres"
+	"cart-api/internal/health"
+	"cart-api/internal/logging"
+	"cart-api/internal/metrics"
 	"cart-api/internal/service"
+	"cart-api/internal/tracing"
 	handler "cart-api/internal/transport/http"
+	"cart-api/internal/transport/http/auth"
+	"cart-api/internal/transport/http/requestid"
 	"context"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
 
-// Run initializes the application by loading configuration, connecting to the database,
-// setting up service and repository layers, and starting the HTTP server with defined routes.
-func Run() {
+// setup loads the configuration and installs the logger it selects as the default logger.
+func setup() (config.Config, *slog.Logger) {
 	cfg, err := config.LoadConfig(".")
 	if err != nil {
 		log.Fatalf("Could not load config: %v", err)
 	}
 
-	db, err := postgres.Connect(cfg)
+	logger, err := logging.New(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		log.Fatalf("Could not set up logging: %v", err)
+	}
+	slog.SetDefault(logger)
+	return cfg, logger
+}
+
+// Serve initializes the application by loading configuration, opening the configured storage,
+// setting up service and repository layers, and starting the HTTP server with defined routes.
+// If migrate is set, pending migrations are applied before the server starts;
+// otherwise they are expected to be applied by a separate deploy step.
+func Serve(migrate bool) {
+	cfg, logger := setup()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		fatal(logger, "could not set up tracing", err)
+	}
+
+	store, err := openStorage(cfg)
 	if err != nil {
-		log.Fatalf("Could not connect to database: %v", err)
+		fatal(logger, "could not open storage", err)
 	}
-	defer db.Close()
+	defer store.close()
 
-	cartRepo := postgres.NewCartRepository(db)
-	cartitemRepo := postgres.NewCartItemRepository(db)
-	cartService := service.NewCartService(cartRepo)
-	cartitemService := service.NewCartItemRepository(cartitemRepo)
-	cartHandler := handler.NewCartHandler(cartService, cartitemService)
+	if migrate && store.migrator != nil {
+		if err := migrateUp(context.Background(), store.migrator, logger); err != nil {
+			fatal(logger, "could not apply migrations", err)
+		}
+	}
+
+	m := metrics.New()
+	if store.db != nil {
+		m.RegisterDBStats(store.db, cfg.StorageDriver)
+	}
+	store.instrument(m)
+	if cfg.CartCacheEnabled {
+		carts := store.withCartCache(cache.NewLRU(cfg.CartCacheSize, cfg.CartCacheTTL))
+		m.RegisterCartCache(carts.Stats)
+	}
+
+	cartService := service.NewCartService(store.carts, store.coupons, store.tx, cfg.CartTTL)
+	cartitemService := service.NewCartItemRepository(store.items, store.carts, store.products, store.tx)
+	productService := service.NewProductService(store.products)
+	orderService := service.NewOrderService(store.carts, store.coupons, store.orders, store.tx)
+	idempotencyService := service.NewIdempotencyService(store.keys, cfg.IdempotencyTTL, cfg.IdempotencyLease)
+	reaper := service.NewReaper(store.carts, store.keys, cfg.ReaperInterval, cfg.CartRetention, logger)
+	cartHandler := handler.NewCartHandler(cartService, cartitemService, logger)
+	productHandler := handler.NewProductHandler(productService, logger)
+	orderHandler := handler.NewOrderHandler(orderService, cartService, logger)
+	idempotency := handler.NewIdempotencyHandler(idempotencyService, logger)
+
+	authenticator, err := auth.New(cfg, logger)
+	if err != nil {
+		fatal(logger, "could not load JWT keys", err)
+	}
 
 	router := http.NewServeMux()
+	// handle registers h for pattern with the request metrics, the server span and the request log of the route.
+	handle := func(pattern string, h http.Handler) {
+		router.Handle(pattern, m.Route(pattern, tracing.Route(pattern, logging.Route(logger, pattern, h))))
+	}
+
+	// caller requires an identity a cart can be owned by. POST /carts stays open, since it issues the anonymous sessions.
+	caller := func(h http.Handler) http.Handler {
+		return auth.RequireCaller(handler.SessionTokenHeader, h)
+	}
+	// catalogWriter requires a token that was granted the scope to change the catalog.
+	catalogWriter := func(h http.Handler) http.Handler {
+		return auth.RequireScope(auth.ScopeCatalogWrite, h)
+	}
 
-	router.Handle("POST /carts", http.HandlerFunc(cartHandler.CreateCart))
-	router.Handle("GET /carts/{id}", http.HandlerFunc(cartHandler.ViewCart))
-	router.Handle("POST /carts/{id}/items", http.HandlerFunc(cartHandler.AddToCart))
-	router.Handle("DELETE /carts/{id}/items/{item_id}", http.HandlerFunc(cartHandler.RemoveFromCart))
+	handle("POST /carts", idempotency.Wrap(http.HandlerFunc(cartHandler.CreateCart)))
+	handle("GET /carts/{id}", caller(http.HandlerFunc(cartHandler.ViewCart)))
+	handle("DELETE /carts/{id}", caller(http.HandlerFunc(cartHandler.DeleteCart)))
+	handle("DELETE /carts/{id}/items", caller(http.HandlerFunc(cartHandler.ClearCart)))
+	handle("PUT /carts/{id}/status", caller(http.HandlerFunc(cartHandler.ChangeStatus)))
+	handle("POST /carts/{id}/merge", caller(http.HandlerFunc(cartHandler.MergeCart)))
+	handle("POST /carts/{id}/items", caller(idempotency.Wrap(http.HandlerFunc(cartHandler.AddToCart))))
+	handle("DELETE /carts/{id}/items/{item_id}", caller(http.HandlerFunc(cartHandler.RemoveFromCart)))
+	handle("PATCH /carts/{id}/items/{item_id}", caller(http.HandlerFunc(cartHandler.UpdateQuantity)))
+	handle("POST /carts/{id}/coupons", caller(http.HandlerFunc(cartHandler.ApplyCoupon)))
+	handle("DELETE /carts/{id}/coupons/{code}", caller(http.HandlerFunc(cartHandler.RemoveCoupon)))
+	handle("POST /carts/{id}/checkout", caller(http.HandlerFunc(orderHandler.Checkout)))
+
+	handle("POST /products", catalogWriter(http.HandlerFunc(productHandler.CreateProduct)))
+	handle("GET /products", http.HandlerFunc(productHandler.ListProducts))
+	handle("GET /products/{sku}", http.HandlerFunc(productHandler.GetProduct))
+	handle("PUT /products/{sku}", catalogWriter(http.HandlerFunc(productHandler.UpdateProduct)))
+	handle("DELETE /products/{sku}", catalogWriter(http.HandlerFunc(productHandler.DeleteProduct)))
+
+	router.Handle("GET /metrics", m.Handler())
+
+	checker := health.New(logger)
+	if store.db != nil {
+		checker.Add("database", store.db.PingContext)
+		checker.Add("migrations", health.Migrations(store.migrator))
+	}
+	router.Handle("GET /healthz", checker.Live())
+	router.Handle("GET /readyz", checker.Ready())
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.ServerPort,
-		Handler: router,
+		Handler: requestid.Middleware(authenticator.Authenticate(router)),
 	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
-		log.Printf("Server is running on port %s", cfg.ServerPort)
+		defer wg.Done()
+		reaper.Run(reaperCtx)
+	}()
+
+	go func() {
+		logger.Info("server is running", "port", cfg.ServerPort)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Could not listen on %s: %v", cfg.ServerPort, err)
+			fatal(logger, "could not listen", err)
 		}
 	}()
 
 	<-stop
-	log.Println("Shutting down server...")
+	checker.Drain()
+	logger.Info("draining before shutdown", "delay", cfg.ShutdownDelay)
+	time.Sleep(cfg.ShutdownDelay)
+	logger.Info("shutting down server")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		fatal(logger, "server forced to shutdown", err)
+	}
+
+	stopReaper()
+	wg.Wait()
+	logger.Info("reaper stopped")
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("could not flush spans", "error", err)
 	}
 
-	log.Println("Server stopped gracefully")
+	logger.Info("server stopped gracefully")
+
+}
 
+// fatal logs err with msg and exits the process.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
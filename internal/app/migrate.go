@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Commands of Migrate.
+const (
+	MigrateUp     = "up"
+	MigrateDown   = "down"
+	MigrateStatus = "status"
+	MigrateTo     = "to"
+)
+
+// Migrate runs a migration command against the configured storage:
+// up applies all pending migrations, down rolls back the latest applied migration,
+// status prints every migration with its state and to migrates up or down to version.
+func Migrate(command string, version int64) {
+	cfg, logger := setup()
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		fatal(logger, "could not open storage", err)
+	}
+	defer store.close()
+	if store.migrator == nil {
+		fatal(logger, "could not migrate", fmt.Errorf("storage driver %q has no migrations", cfg.StorageDriver))
+	}
+
+	ctx := context.Background()
+	switch command {
+	case MigrateUp:
+		err = migrateUp(ctx, store.migrator, logger)
+	case MigrateDown:
+		var result *goose.MigrationResult
+		result, err = store.migrator.Down(ctx)
+		logMigrations(logger, result)
+	case MigrateStatus:
+		err = printMigrationStatus(ctx, store.migrator, os.Stdout)
+	case MigrateTo:
+		err = migrateTo(ctx, store.migrator, version, logger)
+	default:
+		err = fmt.Errorf("unknown migrate command %q", command)
+	}
+	if err != nil {
+		fatal(logger, "could not migrate", err)
+	}
+}
+
+// migrateUp applies all pending migrations of p.
+func migrateUp(ctx context.Context, p *goose.Provider, logger *slog.Logger) error {
+	results, err := p.Up(ctx)
+	logMigrations(logger, results...)
+	if err != nil {
+		return err
+	}
+	version, err := p.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info("database is up to date", "version", version)
+	return nil
+}
+
+// migrateTo applies or rolls back the migrations of p until the database is at version.
+func migrateTo(ctx context.Context, p *goose.Provider, version int64, logger *slog.Logger) error {
+	current, err := p.GetDBVersion(ctx)
+	if err != nil {
+		return err
+	}
+	var results []*goose.MigrationResult
+	switch {
+	case version > current:
+		results, err = p.UpTo(ctx, version)
+	case version < current:
+		results, err = p.DownTo(ctx, version)
+	}
+	logMigrations(logger, results...)
+	if err != nil {
+		return err
+	}
+	logger.Info("database is at the requested version", "version", version)
+	return nil
+}
+
+// logMigrations logs the migrations that were applied or rolled back.
+func logMigrations(logger *slog.Logger, results ...*goose.MigrationResult) {
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		logger.Info("migrated", "direction", r.Direction, "version", r.Source.Version,
+			"source", filepath.Base(r.Source.Path), "duration", r.Duration)
+	}
+}
+
+// printMigrationStatus writes a table of the migrations of p and their state to w.
+func printMigrationStatus(ctx context.Context, p *goose.Provider, w io.Writer) error {
+	statuses, err := p.Status(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tSOURCE")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.State == goose.StateApplied {
+			appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, appliedAt, filepath.Base(s.Source.Path))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// fixtures is the content of a seed file.
+type fixtures struct {
+	Products []productFixture `json:"products"`
+	Carts    []cartFixture    `json:"carts"`
+}
+
+// productFixture is a catalog product; a missing active flag is treated as true.
+type productFixture struct {
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	UnitPrice int64  `json:"unit_price"`
+	Currency  string `json:"currency"`
+	Active    *bool  `json:"active"`
+}
+
+// cartFixture is a cart of a user with its items.
+type cartFixture struct {
+	UserID string        `json:"user_id"`
+	Items  []itemFixture `json:"items"`
+}
+
+type itemFixture struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+// Seed loads the products and carts of the JSON fixtures file at path into the configured storage.
+// Products that already exist are updated, so the catalog can be seeded repeatedly; carts are always created anew.
+// Fixtures go through the services and their validation. They are loaded one by one,
+// so the fixtures before a failing one stay in the storage.
+func Seed(path string) {
+	cfg, logger := setup()
+
+	if cfg.StorageDriver == DriverMemory {
+		fatal(logger, "could not seed", errors.New("the memory storage driver loses seeded data when the command exits"))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fatal(logger, "could not read fixtures", err)
+	}
+	var f fixtures
+	if err := json.Unmarshal(data, &f); err != nil {
+		fatal(logger, "could not parse fixtures", fmt.Errorf("%s: %w", path, err))
+	}
+
+	store, err := openStorage(cfg)
+	if err != nil {
+		fatal(logger, "could not open storage", err)
+	}
+	defer store.close()
+
+	s := seeder{
+		products: service.NewProductService(store.products),
+		carts:    service.NewCartService(store.carts, store.coupons, store.tx, cfg.CartTTL),
+		items:    service.NewCartItemRepository(store.items, store.carts, store.products, store.tx),
+		logger:   logger,
+	}
+	if err := s.seed(context.Background(), f); err != nil {
+		fatal(logger, "could not seed", err)
+	}
+}
+
+// seeder loads fixtures with the services of the application.
+type seeder struct {
+	products *service.ProductService
+	carts    *service.CartService
+	items    *service.CartItemService
+	logger   *slog.Logger
+}
+
+func (s seeder) seed(ctx context.Context, f fixtures) error {
+	for _, p := range f.Products {
+		if err := s.seedProduct(ctx, p); err != nil {
+			return fmt.Errorf("product %q: %w", p.SKU, err)
+		}
+	}
+	for i, c := range f.Carts {
+		if err := s.seedCart(ctx, c); err != nil {
+			return fmt.Errorf("cart %d of user %q: %w", i, c.UserID, err)
+		}
+	}
+	s.logger.Info("seeded fixtures", "products", len(f.Products), "carts", len(f.Carts))
+	return nil
+}
+
+// seedProduct creates the product, or updates it if a product with its SKU already exists.
+func (s seeder) seedProduct(ctx context.Context, p productFixture) error {
+	active := true
+	if p.Active != nil {
+		active = *p.Active
+	}
+	product := model.Product{SKU: p.SKU, Name: p.Name, UnitPrice: p.UnitPrice, Currency: p.Currency, Active: active}
+	err := s.products.CreateProduct(ctx, &product)
+	if errors.Is(err, carterror.ErrProductAlreadyExists) {
+		err = s.products.UpdateProduct(ctx, &product)
+	}
+	return err
+}
+
+// seedCart creates a cart owned by the user of the fixture and adds its items.
+func (s seeder) seedCart(ctx context.Context, c cartFixture) error {
+	if c.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	cart, err := s.carts.CreateCart(ctx, model.Owner{Kind: model.OwnerUser, ID: c.UserID})
+	if err != nil {
+		return err
+	}
+	for _, item := range c.Items {
+		if _, err := s.items.AddToCart(ctx, &model.CartItem{CartID: cart.ID, SKU: item.SKU, Quantity: item.Quantity}, nil); err != nil {
+			return fmt.Errorf("item %q: %w", item.SKU, err)
+		}
+	}
+	s.logger.Info("seeded cart", "cart_id", cart.ID, "user_id", c.UserID, "items", len(c.Items))
+	return nil
+}
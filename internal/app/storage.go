@@ -0,0 +1,131 @@
+package app
+
+import (
+	"cart-api/internal/cache"
+	"cart-api/internal/config"
+	"cart-api/internal/db/memory"
+	"cart-api/internal/db/postgres"
+	"cart-api/internal/db/sqlite"
+	"cart-api/internal/metrics"
+	"cart-api/internal/service"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Storage drivers selectable with STORAGE_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// storage holds the repositories of the configured storage backend.
+type storage struct {
+	carts interface {
+		service.CartStorage
+		service.CartReaperStorage
+	}
+	items    service.CartItemStorage
+	products service.ProductStorage
+	coupons  service.CouponStorage
+	orders   service.OrderStorage
+	keys     service.IdempotencyStorage
+	tx       service.Transactor
+	// db is the connection pool of the SQL backends; it is nil for the memory backend.
+	db *sql.DB
+	// migrator applies the migrations of the SQL backends to db; it is nil for the memory backend.
+	migrator *goose.Provider
+	close    func() error
+}
+
+// openStorage connects to the storage backend selected by cfg.StorageDriver without applying migrations.
+// The memory backend starts empty and loses its data when the application stops.
+func openStorage(cfg config.Config) (*storage, error) {
+	switch cfg.StorageDriver {
+	case DriverPostgres:
+		db, err := postgres.Connect(cfg)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := postgres.NewMigrator(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return &storage{
+			carts:    postgres.NewCartRepository(db),
+			items:    postgres.NewCartItemRepository(db),
+			products: postgres.NewProductRepository(db),
+			coupons:  postgres.NewCouponRepository(db),
+			orders:   postgres.NewOrderRepository(db),
+			keys:     postgres.NewIdempotencyRepository(db),
+			tx:       postgres.NewTransactor(db),
+			db:       db.DB,
+			migrator: migrator,
+			close:    db.Close,
+		}, nil
+	case DriverSQLite:
+		db, err := sqlite.Connect(cfg)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := sqlite.NewMigrator(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		return &storage{
+			carts:    sqlite.NewCartRepository(db),
+			items:    sqlite.NewCartItemRepository(db),
+			products: sqlite.NewProductRepository(db),
+			coupons:  sqlite.NewCouponRepository(db),
+			orders:   sqlite.NewOrderRepository(db),
+			keys:     sqlite.NewIdempotencyRepository(db),
+			tx:       sqlite.NewTransactor(db),
+			db:       db.DB,
+			migrator: migrator,
+			close:    db.Close,
+		}, nil
+	case DriverMemory:
+		store := memory.NewStore()
+		return &storage{
+			carts:    memory.NewCartRepository(store),
+			items:    memory.NewCartItemRepository(store),
+			products: memory.NewProductRepository(store),
+			coupons:  memory.NewCouponRepository(store),
+			orders:   memory.NewOrderRepository(store),
+			keys:     memory.NewIdempotencyRepository(store),
+			tx:       memory.NewTransactor(store),
+			close:    func() error { return nil },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// instrument times the calls to the repositories of the storage and counts the business events they record
+// once the units of work recording them have committed.
+// It must be applied before withCartCache, so that reads served from the cache are not timed as queries.
+func (s *storage) instrument(m *metrics.Metrics) {
+	s.carts = m.Carts(s.carts)
+	s.items = m.Items(s.items)
+	s.products = m.Products(s.products)
+	s.coupons = m.Coupons(s.coupons)
+	s.orders = m.Orders(s.orders)
+	s.keys = m.IdempotencyKeys(s.keys)
+	s.tx = m.Transactor(s.tx)
+}
+
+// withCartCache serves the carts of the storage from c and drops them from c whenever they change.
+// It returns the cached cart storage, which counts the cache hits and misses.
+func (s *storage) withCartCache(c cache.CartCache) *cache.CartStorage {
+	carts := cache.New(s.carts, c)
+	s.carts = carts
+	s.items = carts.Items(s.items)
+	s.coupons = carts.Coupons(s.coupons)
+	s.orders = carts.Orders(s.orders)
+	s.tx = carts.Transactor(s.tx)
+	return carts
+}
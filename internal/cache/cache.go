@@ -0,0 +1,39 @@
+// Package cache serves carts from a cache in front of the storage backend.
+// Reads go through the cache and every change of a cart drops it from the cache,
+// so the cached copy is never older than the last change made through this process.
+package cache
+
+import (
+	"cart-api/internal/model"
+	"context"
+)
+
+// CartCache stores carts by their ID.
+// Implementations must be safe for concurrent use and must not share carts with their callers:
+// Set keeps a copy of the cart and Get returns a copy that the caller may modify.
+// Implementations backed by a remote store, e.g. Redis, treat their failures as misses and log them.
+type CartCache interface {
+	Get(ctx context.Context, id string) (*model.Cart, bool)
+	Set(ctx context.Context, cart *model.Cart)
+	Delete(ctx context.Context, ids ...string)
+	// Clear drops every cart, for changes that affect carts that are not known by their ID.
+	Clear(ctx context.Context)
+}
+
+// cloneCart returns a copy of cart that shares no slices with it.
+func cloneCart(cart *model.Cart) *model.Cart {
+	clone := *cart
+	if cart.Items != nil {
+		clone.Items = append([]model.CartItem{}, cart.Items...)
+	}
+	if cart.Transitions != nil {
+		clone.Transitions = append([]model.StatusTransition{}, cart.Transitions...)
+	}
+	if cart.Discounts != nil {
+		clone.Discounts = append([]model.AppliedDiscount{}, cart.Discounts...)
+	}
+	if cart.RejectedCoupons != nil {
+		clone.RejectedCoupons = append([]model.RejectedCoupon{}, cart.RejectedCoupons...)
+	}
+	return &clone
+}
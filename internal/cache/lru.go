@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"cart-api/internal/model"
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process CartCache that holds up to a fixed number of carts
+// and evicts the least recently used one when it is full.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+// lruEntry is an element of the recency list of LRU.
+type lruEntry struct {
+	cart      *model.Cart
+	expiresAt time.Time
+}
+
+// NewLRU creates a new instance of LRU that holds up to capacity carts for at most ttl.
+// A zero ttl keeps carts until they are evicted or dropped.
+func NewLRU(capacity int, ttl time.Duration) *LRU {
+	return &LRU{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+		now:      time.Now,
+	}
+}
+
+// Get returns a copy of the cart with the given ID if it is cached and has not expired.
+func (c *LRU) Get(_ context.Context, id string) (*model.Cart, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return cloneCart(entry.cart), true
+}
+
+// Set caches a copy of cart, evicting the least recently used cart if the cache is full.
+func (c *LRU) Set(_ context.Context, cart *model.Cart) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &lruEntry{cart: cloneCart(cart), expiresAt: c.now().Add(c.ttl)}
+	if element, ok := c.entries[cart.ID]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[cart.ID] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// Delete drops the carts with the given IDs.
+func (c *LRU) Delete(_ context.Context, ids ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range ids {
+		if element, ok := c.entries[id]; ok {
+			c.remove(element)
+		}
+	}
+}
+
+// Clear drops every cart.
+func (c *LRU) Clear(_ context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// Len returns the number of cached carts, including expired ones that have not been dropped yet.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove drops element from the cache. The caller must hold c.mu.
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).cart.ID)
+}
@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"cart-api/internal/cache"
+	"cart-api/internal/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_GetReturnsCopy(t *testing.T) {
+	c := cache.NewLRU(10, 0)
+	c.Set(context.Background(), &model.Cart{ID: "a", Items: []model.CartItem{{SKU: "apple", Quantity: 1}}})
+
+	cart, ok := c.Get(context.Background(), "a")
+	require.True(t, ok)
+	cart.Items[0].Quantity = 5
+	cart.Subtotal = 500
+
+	cart, ok = c.Get(context.Background(), "a")
+	require.True(t, ok)
+	assert.Equal(t, 1, cart.Items[0].Quantity)
+	assert.Zero(t, cart.Subtotal)
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewLRU(2, 0)
+	c.Set(context.Background(), &model.Cart{ID: "a"})
+	c.Set(context.Background(), &model.Cart{ID: "b"})
+	_, ok := c.Get(context.Background(), "a")
+	require.True(t, ok)
+
+	c.Set(context.Background(), &model.Cart{ID: "c"})
+
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get(context.Background(), "b")
+	assert.False(t, ok)
+	_, ok = c.Get(context.Background(), "a")
+	assert.True(t, ok)
+	_, ok = c.Get(context.Background(), "c")
+	assert.True(t, ok)
+}
+
+func TestLRU_Expires(t *testing.T) {
+	c := cache.NewLRU(10, 10*time.Millisecond)
+	c.Set(context.Background(), &model.Cart{ID: "a"})
+
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := c.Get(context.Background(), "a")
+	assert.False(t, ok)
+	assert.Zero(t, c.Len())
+}
+
+func TestLRU_DeleteAndClear(t *testing.T) {
+	c := cache.NewLRU(10, 0)
+	for _, id := range []string{"a", "b", "c"} {
+		c.Set(context.Background(), &model.Cart{ID: id})
+	}
+
+	c.Delete(context.Background(), "a", "b")
+	_, ok := c.Get(context.Background(), "a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+
+	c.Clear(context.Background())
+	assert.Zero(t, c.Len())
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CartStore is the cart storage of a backend, including the operations of the reaper.
+type CartStore interface {
+	service.CartStorage
+	service.CartReaperStorage
+}
+
+// Stats counts the reads of carts by whether they were served from the cache.
+type Stats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// invalidator drops changed carts from the cache.
+// Within a unit of work the carts are dropped again once it has ended,
+// because until then concurrent reads still see the carts as they were before the change.
+type invalidator struct {
+	cache CartCache
+	// generation is incremented whenever carts are dropped,
+	// so that a read that raced with a change does not cache what it read.
+	generation atomic.Uint64
+}
+
+// pendingKey is the context key of the carts changed by the unit of work running in the context.
+type pendingKey struct{}
+
+// pending collects the carts changed by a unit of work.
+type pending struct {
+	mu    sync.Mutex
+	ids   []string
+	clear bool
+}
+
+// drop removes the carts with the given IDs from the cache.
+func (i *invalidator) drop(ctx context.Context, ids ...string) {
+	i.generation.Add(1)
+	i.cache.Delete(ctx, ids...)
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.mu.Lock()
+		p.ids = append(p.ids, ids...)
+		p.mu.Unlock()
+	}
+}
+
+// dropAll removes every cart from the cache.
+func (i *invalidator) dropAll(ctx context.Context) {
+	i.generation.Add(1)
+	i.cache.Clear(ctx)
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.mu.Lock()
+		p.clear = true
+		p.mu.Unlock()
+	}
+}
+
+// flush drops the carts changed by a unit of work that has ended.
+func (i *invalidator) flush(ctx context.Context, p *pending) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clear {
+		i.dropAll(ctx)
+		return
+	}
+	if len(p.ids) > 0 {
+		i.drop(ctx, p.ids...)
+	}
+}
+
+// inUnitOfWork reports whether ctx belongs to a unit of work started with Transactor.
+func inUnitOfWork(ctx context.Context) bool {
+	_, ok := ctx.Value(pendingKey{}).(*pending)
+	return ok
+}
+
+// CartStorage serves Get from a CartCache and drops carts from it when they change.
+type CartStorage struct {
+	CartStore
+	*invalidator
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New wraps the cart storage of a backend with cache.
+// The item, coupon and order storages and the transactor of the backend must be wrapped
+// with the methods of the returned CartStorage, so that their changes drop the carts as well.
+func New(carts CartStore, cache CartCache) *CartStorage {
+	return &CartStorage{CartStore: carts, invalidator: &invalidator{cache: cache}}
+}
+
+// Get returns the cart from the cache, or reads it from the storage and caches it.
+// Within a unit of work the cache is bypassed, so that the cart is read within its transaction.
+func (s *CartStorage) Get(ctx context.Context, id string) (*model.Cart, error) {
+	if inUnitOfWork(ctx) {
+		return s.CartStore.Get(ctx, id)
+	}
+	if cart, ok := s.cache.Get(ctx, id); ok {
+		s.hits.Add(1)
+		return cart, nil
+	}
+	s.misses.Add(1)
+
+	generation := s.generation.Load()
+	cart, err := s.CartStore.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.generation.Load() == generation {
+		s.cache.Set(ctx, cart)
+	}
+	return cart, nil
+}
+
+// Stats returns the number of reads served from the cache and from the storage.
+func (s *CartStorage) Stats() Stats {
+	return Stats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+}
+
+// Delete deletes the cart and drops it from the cache.
+func (s *CartStorage) Delete(ctx context.Context, id string) error {
+	defer s.drop(ctx, id)
+	return s.CartStore.Delete(ctx, id)
+}
+
+// Clear removes the items of the cart and drops it from the cache.
+func (s *CartStorage) Clear(ctx context.Context, id string) error {
+	defer s.drop(ctx, id)
+	return s.CartStore.Clear(ctx, id)
+}
+
+// SetStatus changes the status of the cart and drops it from the cache.
+func (s *CartStorage) SetStatus(ctx context.Context, id string, from, to model.CartStatus) error {
+	defer s.drop(ctx, id)
+	return s.CartStore.SetStatus(ctx, id, from, to)
+}
+
+// Merge merges the source cart into the target cart and drops both from the cache.
+func (s *CartStorage) Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error {
+	defer s.drop(ctx, targetID, sourceID)
+	return s.CartStore.Merge(ctx, targetID, sourceID, maxQuantity)
+}
+
+// ExpireStale expires stale carts and drops every cart from the cache, since the expired carts are not known by their ID.
+func (s *CartStorage) ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error) {
+	expired, err := s.CartStore.ExpireStale(ctx, now, from)
+	if expired > 0 {
+		s.dropAll(ctx)
+	}
+	return expired, err
+}
+
+// PurgeExpired deletes expired carts and drops every cart from the cache.
+func (s *CartStorage) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.CartStore.PurgeExpired(ctx, before)
+	if purged > 0 {
+		s.dropAll(ctx)
+	}
+	return purged, err
+}
+
+// Items wraps the item storage of the backend, so that item changes drop the cart from the cache.
+func (s *CartStorage) Items(items service.CartItemStorage) service.CartItemStorage {
+	return &itemStorage{CartItemStorage: items, invalidator: s.invalidator}
+}
+
+// Coupons wraps the coupon storage of the backend, so that attaching and detaching coupons drops the cart from the cache.
+func (s *CartStorage) Coupons(coupons service.CouponStorage) service.CouponStorage {
+	return &couponStorage{CouponStorage: coupons, invalidator: s.invalidator}
+}
+
+// Orders wraps the order storage of the backend, so that checking a cart out drops it from the cache.
+func (s *CartStorage) Orders(orders service.OrderStorage) service.OrderStorage {
+	return &orderStorage{OrderStorage: orders, invalidator: s.invalidator}
+}
+
+// Transactor wraps the transactor of the backend, so that the carts changed by a unit of work
+// are dropped from the cache once more after it has ended.
+func (s *CartStorage) Transactor(tx service.Transactor) service.Transactor {
+	return &transactor{Transactor: tx, invalidator: s.invalidator}
+}
+
+type itemStorage struct {
+	service.CartItemStorage
+	*invalidator
+}
+
+func (s *itemStorage) Create(ctx context.Context, item *model.CartItem, maxQuantity int) (bool, error) {
+	defer s.drop(ctx, item.CartID)
+	return s.CartItemStorage.Create(ctx, item, maxQuantity)
+}
+
+func (s *itemStorage) Delete(ctx context.Context, cartID, cartItemID string) error {
+	defer s.drop(ctx, cartID)
+	return s.CartItemStorage.Delete(ctx, cartID, cartItemID)
+}
+
+func (s *itemStorage) UpdateQuantity(ctx context.Context, cartID, cartItemID string, quantity int) (*model.CartItem, error) {
+	defer s.drop(ctx, cartID)
+	return s.CartItemStorage.UpdateQuantity(ctx, cartID, cartItemID, quantity)
+}
+
+type couponStorage struct {
+	service.CouponStorage
+	*invalidator
+}
+
+func (s *couponStorage) Attach(ctx context.Context, cartID, code string) error {
+	defer s.drop(ctx, cartID)
+	return s.CouponStorage.Attach(ctx, cartID, code)
+}
+
+func (s *couponStorage) Detach(ctx context.Context, cartID, code string) error {
+	defer s.drop(ctx, cartID)
+	return s.CouponStorage.Detach(ctx, cartID, code)
+}
+
+type orderStorage struct {
+	service.OrderStorage
+	*invalidator
+}
+
+func (s *orderStorage) Create(ctx context.Context, order *model.Order) error {
+	defer s.drop(ctx, order.CartID)
+	return s.OrderStorage.Create(ctx, order)
+}
+
+type transactor struct {
+	service.Transactor
+	*invalidator
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inUnitOfWork(ctx) {
+		return t.Transactor.WithinTx(ctx, fn)
+	}
+	p := &pending{}
+	defer t.flush(ctx, p)
+	return t.Transactor.WithinTx(context.WithValue(ctx, pendingKey{}, p), fn)
+}
@@ -0,0 +1,106 @@
+package cache_test
+
+import (
+	"cart-api/internal/cache"
+	"cart-api/internal/db/memory"
+	"cart-api/internal/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var owner = model.Owner{Kind: model.OwnerUser, ID: "user-1"}
+
+// newCachedStore returns the cached cart storage of a memory store with one product and one cart.
+func newCachedStore(t *testing.T) (*memory.Store, *cache.CartStorage, *model.Cart) {
+	t.Helper()
+	store := memory.NewStore()
+	apple := model.Product{SKU: "apple", Name: "Apple", UnitPrice: 100, Currency: "USD", Active: true}
+	require.NoError(t, memory.NewProductRepository(store).Create(context.Background(), &apple))
+
+	carts := cache.New(memory.NewCartRepository(store), cache.NewLRU(10, time.Minute))
+	cart, err := carts.Create(context.Background(), owner, time.Hour)
+	require.NoError(t, err)
+	return store, carts, cart
+}
+
+func TestCartStorage_ReadThrough(t *testing.T) {
+	_, carts, cart := newCachedStore(t)
+
+	for i := 0; i < 3; i++ {
+		got, err := carts.Get(context.Background(), cart.ID)
+		require.NoError(t, err)
+		assert.Equal(t, cart.ID, got.ID)
+	}
+
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 1}, carts.Stats())
+}
+
+func TestCartStorage_ItemChangesDropCart(t *testing.T) {
+	store, carts, cart := newCachedStore(t)
+	items := carts.Items(memory.NewCartItemRepository(store))
+
+	_, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+
+	item := &model.CartItem{CartID: cart.ID, SKU: "apple", Quantity: 2, UnitPrice: 100, Currency: "USD"}
+	_, err = items.Create(context.Background(), item, 999)
+	require.NoError(t, err)
+
+	got, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, cart.Version+1, got.Version)
+
+	_, err = items.UpdateQuantity(context.Background(), cart.ID, item.ID, 3)
+	require.NoError(t, err)
+
+	got, err = carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, got.Items[0].Quantity)
+	assert.Equal(t, cache.Stats{Hits: 0, Misses: 3}, carts.Stats())
+}
+
+func TestCartStorage_UnitOfWork(t *testing.T) {
+	store, carts, cart := newCachedStore(t)
+	tx := carts.Transactor(memory.NewTransactor(store))
+
+	_, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := carts.SetStatus(ctx, cart.ID, model.CartActive, model.CartLocked); err != nil {
+			return err
+		}
+		got, err := carts.Get(ctx, cart.ID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, model.CartLocked, got.Status, "reads within a unit of work bypass the cache")
+		return nil
+	})
+	require.NoError(t, err)
+
+	got, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.CartLocked, got.Status)
+	assert.Equal(t, cache.Stats{Hits: 0, Misses: 2}, carts.Stats())
+}
+
+func TestCartStorage_ExpireStaleDropsAll(t *testing.T) {
+	_, carts, cart := newCachedStore(t)
+
+	_, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+
+	expired, err := carts.ExpireStale(context.Background(), time.Now().Add(2*time.Hour), []model.CartStatus{model.CartActive})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), expired)
+
+	got, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.CartExpired, got.Status)
+}
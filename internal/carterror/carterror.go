@@ -14,6 +14,66 @@ var (
 	ErrFailedToRetrieveCart      = errors.New("failed to retrieve cart")
 	ErrFailedToRetrieveCartItems = errors.New("failed to retrieve cart items")
 	ErrFailedPostgresOpperation  = errors.New("failed to make a query to postgres")
+	ErrFailedSQLiteOperation     = errors.New("failed to make a query to sqlite")
 	ErrQuantityMustBePositive    = errors.New("quantity must be positive")
-	ErrMissingProduct            = errors.New("missing product")
+	ErrMissingSKU                = errors.New("missing sku")
+	ErrCartItemDoesNotExist      = errors.New("cart item does not exist")
+	ErrProductDoesNotExist       = errors.New("product does not exist")
+	ErrProductInactive           = errors.New("product is not available")
+	ErrProductAlreadyExists      = errors.New("product already exists")
+	ErrProductInUse              = errors.New("product is referenced by cart items")
+	ErrMissingProductName        = errors.New("missing product name")
+	ErrPriceMustNotBeNegative    = errors.New("price must not be negative")
+	ErrInvalidCurrency           = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrCurrencyMismatch          = errors.New("cart items must share the same currency")
+	ErrCouponDoesNotExist        = errors.New("coupon does not exist")
+	ErrCouponNotApplied          = errors.New("coupon is not applied to the cart")
+	ErrMissingCouponCode         = errors.New("missing coupon code")
+	ErrCouponNotYetValid         = errors.New("coupon is not valid yet")
+	ErrCouponExpired             = errors.New("coupon has expired")
+	ErrCouponExhausted           = errors.New("coupon usage limit has been reached")
+	ErrCouponBelowMinimum        = errors.New("cart subtotal is below the coupon minimum")
+	ErrCouponNotApplicable       = errors.New("coupon does not apply to the cart items")
+	ErrCartCheckedOut            = errors.New("cart has already been checked out")
+	ErrCartEmpty                 = errors.New("cart is empty")
+	ErrCartNotActive             = errors.New("cart is not active")
+	ErrInvalidStatus             = errors.New("invalid cart status")
+	ErrInvalidStatusTransition   = errors.New("cart status transition is not allowed")
+	ErrSourceCartIDRequired      = errors.New("source cart id is required")
+	ErrMergeIntoItself           = errors.New("cart cannot be merged into itself")
+	ErrRequestBodyTooLarge       = errors.New("request body is too large")
+	ErrQuantityTooLarge          = errors.New("quantity exceeds the maximum per cart line")
+	ErrPreconditionFailed        = errors.New("cart was modified since it was read")
+	ErrIdempotencyKeyReused      = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress     = errors.New("a request with the same idempotency key is still being processed")
 )
+
+// StatusError is returned when an operation requires an active cart but the cart is in another status.
+// It matches ErrCartNotActive with errors.Is, and also ErrCartCheckedOut if the cart has been checked out.
+type StatusError struct {
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return "cart is " + e.Status
+}
+
+// Is reports whether target is ErrCartNotActive, or ErrCartCheckedOut for checked out carts.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrCartNotActive || (target == ErrCartCheckedOut && e.Status == "checked_out")
+}
+
+// VersionError is returned when a conditional change is rejected because the cart has changed.
+// It matches ErrPreconditionFailed with errors.Is and carries the current version of the cart.
+type VersionError struct {
+	Version int64
+}
+
+func (e *VersionError) Error() string {
+	return ErrPreconditionFailed.Error()
+}
+
+// Is reports whether target is ErrPreconditionFailed.
+func (e *VersionError) Is(target error) bool {
+	return target == ErrPreconditionFailed
+}
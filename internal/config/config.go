@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -13,6 +16,58 @@ type Config struct {
 	DBPassword string `mapstructure:"DB_PASSWORD"`
 	DBName     string `mapstructure:"DB_NAME"`
 	ServerPort string `mapstructure:"SERVER_PORT"`
+	// ShutdownDelay is how long the server keeps serving after SIGTERM with a failing readiness probe,
+	// so that load balancers stop routing requests to it before it shuts down.
+	ShutdownDelay time.Duration `mapstructure:"SHUTDOWN_DELAY"`
+
+	// StorageDriver selects the storage backend: "postgres", "sqlite" or "memory".
+	// The memory backend needs no database and loses its data on restart.
+	StorageDriver string `mapstructure:"STORAGE_DRIVER"`
+	// SQLitePath is the database file of the sqlite storage driver.
+	SQLitePath string `mapstructure:"SQLITE_PATH"`
+
+	// CartTTL is how long a cart lives without changes before it expires.
+	CartTTL time.Duration `mapstructure:"CART_TTL"`
+	// CartRetention is how long expired carts are kept before they are deleted.
+	CartRetention time.Duration `mapstructure:"CART_RETENTION"`
+	// ReaperInterval is how often stale carts are expired and purged. It must be positive.
+	ReaperInterval time.Duration `mapstructure:"REAPER_INTERVAL"`
+	// CartCacheEnabled serves viewed carts from an in-process cache that is dropped whenever a cart changes.
+	// Disable it when several instances share the database, since they do not see each other's changes.
+	CartCacheEnabled bool `mapstructure:"CART_CACHE_ENABLED"`
+	// CartCacheSize is the maximum number of cached carts.
+	CartCacheSize int `mapstructure:"CART_CACHE_SIZE"`
+	// CartCacheTTL bounds how long a cart stays cached.
+	CartCacheTTL time.Duration `mapstructure:"CART_CACHE_TTL"`
+	// IdempotencyTTL is how long responses are replayed for retries with the same idempotency key.
+	IdempotencyTTL time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	// IdempotencyLease is how long a request holds its idempotency key before a retry may take it over,
+	// e.g. after the process crashed while handling the request. It must be positive.
+	IdempotencyLease time.Duration `mapstructure:"IDEMPOTENCY_LEASE"`
+
+	// TracingExporter selects where spans are exported: "none", "stdout" or "otlp".
+	TracingExporter string `mapstructure:"TRACING_EXPORTER"`
+	// TracingOTLPEndpoint is the host and port of the OTLP/HTTP collector of the otlp exporter.
+	TracingOTLPEndpoint string `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	// TracingOTLPInsecure sends spans to the collector over plain HTTP instead of HTTPS.
+	TracingOTLPInsecure bool `mapstructure:"TRACING_OTLP_INSECURE"`
+	// TracingSampleRatio is the share of new traces that are sampled; traces started by the caller follow its decision.
+	TracingSampleRatio float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	// LogFormat selects the log output: "text" or "json".
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+	// LogLevel is the minimum level of logged records: "debug", "info", "warn" or "error".
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+
+	// JWTSecret is the shared secret of HS256 signed tokens.
+	JWTSecret string `mapstructure:"JWT_SECRET"`
+	// JWTPublicKeyFile is the path of the PEM encoded RSA public key of RS256 signed tokens.
+	JWTPublicKeyFile string `mapstructure:"JWT_PUBLIC_KEY_FILE"`
+	// JWTJWKSFile is the path of a local JWKS file with keys selected by the kid of the token.
+	JWTJWKSFile string `mapstructure:"JWT_JWKS_FILE"`
+	// JWTIssuer and JWTAudience, when set, must match the iss and aud claims of the token.
+	JWTIssuer   string `mapstructure:"JWT_ISSUER"`
+	JWTAudience string `mapstructure:"JWT_AUDIENCE"`
 }
 
 // LoadConfig reads configuration  and returns a Config struct.
@@ -22,6 +77,29 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigName("app")
 	viper.SetConfigType("env")
 
+	viper.SetDefault("SHUTDOWN_DELAY", 5*time.Second)
+	viper.SetDefault("STORAGE_DRIVER", "postgres")
+	viper.SetDefault("SQLITE_PATH", "cart.db")
+	viper.SetDefault("CART_TTL", 30*24*time.Hour)
+	viper.SetDefault("CART_RETENTION", 7*24*time.Hour)
+	viper.SetDefault("REAPER_INTERVAL", time.Hour)
+	viper.SetDefault("IDEMPOTENCY_TTL", 24*time.Hour)
+	viper.SetDefault("IDEMPOTENCY_LEASE", time.Minute)
+	viper.SetDefault("CART_CACHE_ENABLED", true)
+	viper.SetDefault("CART_CACHE_SIZE", 10000)
+	viper.SetDefault("CART_CACHE_TTL", 5*time.Minute)
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	viper.SetDefault("TRACING_OTLP_INSECURE", false)
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("LOG_FORMAT", "text")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("JWT_SECRET", "")
+	viper.SetDefault("JWT_PUBLIC_KEY_FILE", "")
+	viper.SetDefault("JWT_JWKS_FILE", "")
+	viper.SetDefault("JWT_ISSUER", "")
+	viper.SetDefault("JWT_AUDIENCE", "")
+
 	viper.AutomaticEnv()
 
 	err = viper.ReadInConfig()
@@ -30,5 +108,16 @@ func LoadConfig(path string) (config Config, err error) {
 	}
 
 	err = viper.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	if config.ReaperInterval <= 0 {
+		err = fmt.Errorf("REAPER_INTERVAL must be positive, got %s", config.ReaperInterval)
+		return
+	}
+	if config.IdempotencyLease <= 0 {
+		err = fmt.Errorf("IDEMPOTENCY_LEASE must be positive, got %s", config.IdempotencyLease)
+	}
 	return
 }
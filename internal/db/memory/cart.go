@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"time"
+)
+
+// CartRepository keeps carts in a Store.
+type CartRepository struct {
+	store *Store
+}
+
+// NewCartRepository creates a new instance of CartRepository.
+func NewCartRepository(store *Store) *CartRepository {
+	return &CartRepository{store: store}
+}
+
+// Create stores a new cart owned by owner that expires after ttl and returns the created cart.
+// The cart is initialized with an empty list of items.
+func (r *CartRepository) Create(ctx context.Context, owner model.Owner, ttl time.Duration) (*model.Cart, error) {
+	defer r.store.lock(ctx)()
+
+	now := r.store.now()
+	record := &cartRecord{
+		cart: model.Cart{
+			ID:        newID(),
+			Status:    model.CartActive,
+			Version:   1,
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		},
+		owner: owner,
+		ttl:   ttl,
+	}
+	r.store.data.carts[record.cart.ID] = record
+
+	cart := record.cart
+	cart.Items = []model.CartItem{}
+	cart.Transitions = []model.StatusTransition{}
+	return &cart, nil
+}
+
+// GetOwner retrieves the owner of a cart by its ID.
+func (r *CartRepository) GetOwner(ctx context.Context, id string) (model.Owner, error) {
+	defer r.store.lock(ctx)()
+
+	record, ok := r.store.data.carts[id]
+	if !ok {
+		return model.Owner{}, carterror.ErrCartDoesNotExist
+	}
+	return record.owner, nil
+}
+
+// Lock returns the cart with its status and version but without its items.
+// Within a unit of work the whole store is held already, so the cart needs no lock of its own.
+func (r *CartRepository) Lock(ctx context.Context, id string) (*model.Cart, error) {
+	defer r.store.lock(ctx)()
+
+	record, ok := r.store.data.carts[id]
+	if !ok {
+		return nil, carterror.ErrCartDoesNotExist
+	}
+	return &model.Cart{ID: record.cart.ID, Status: record.cart.Status, Version: record.cart.Version}, nil
+}
+
+// Get retrieves a cart by its ID, including all associated cart items.
+func (r *CartRepository) Get(ctx context.Context, id string) (*model.Cart, error) {
+	defer r.store.lock(ctx)()
+
+	record, ok := r.store.data.carts[id]
+	if !ok {
+		return nil, carterror.ErrCartDoesNotExist
+	}
+	cart := record.cart
+	cart.Items = append([]model.CartItem{}, record.items...)
+	cart.Transitions = append([]model.StatusTransition{}, record.transitions...)
+	return &cart, nil
+}
+
+// Delete removes a cart by its ID together with its items, coupons and transitions.
+func (r *CartRepository) Delete(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.data.carts[id]; !ok {
+		return carterror.ErrCartDoesNotExist
+	}
+	delete(r.store.data.carts, id)
+	return nil
+}
+
+// Clear removes all items from the cart with the given ID, keeping the cart itself.
+func (r *CartRepository) Clear(ctx context.Context, id string) error {
+	defer r.store.lock(ctx)()
+
+	record, err := r.store.data.cart(id)
+	if err != nil {
+		return err
+	}
+	record.items = nil
+	record.touch(r.store.now())
+	return nil
+}
+
+// SetStatus changes the status of the cart from the given status to another one
+// and records the transition. It fails if the cart is no longer in the from status.
+func (r *CartRepository) SetStatus(ctx context.Context, id string, from, to model.CartStatus) error {
+	defer r.store.lock(ctx)()
+
+	record, ok := r.store.data.carts[id]
+	if !ok {
+		return carterror.ErrCartDoesNotExist
+	}
+	if record.cart.Status != from {
+		return carterror.ErrInvalidStatusTransition
+	}
+	record.setStatus(to, r.store.now())
+	return nil
+}
+
+// setStatus moves the cart to the given status and records the transition.
+// Moving the cart to the checked out status also records the checkout time.
+func (r *cartRecord) setStatus(to model.CartStatus, now time.Time) {
+	r.transitions = append(r.transitions, model.StatusTransition{From: r.cart.Status, To: to, At: now})
+	r.cart.Status = to
+	if to == model.CartCheckedOut {
+		r.cart.CheckedOutAt = &now
+	}
+	r.cart.UpdatedAt = now
+	r.cart.Version++
+}
+
+// ExpireStale moves every cart in one of the given statuses whose expiry time has passed
+// to the expired status, records the transitions and returns the number of expired carts.
+func (r *CartRepository) ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error) {
+	defer r.store.lock(ctx)()
+
+	var expired int64
+	for _, record := range r.store.data.carts {
+		if !containsStatus(from, record.cart.Status) || record.cart.ExpiresAt.After(now) {
+			continue
+		}
+		record.setStatus(model.CartExpired, now)
+		expired++
+	}
+	return expired, nil
+}
+
+// PurgeExpired deletes the carts that have been expired since before the given time
+// and returns the number of deleted carts.
+func (r *CartRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	defer r.store.lock(ctx)()
+
+	var purged int64
+	for id, record := range r.store.data.carts {
+		if record.cart.Status == model.CartExpired && record.cart.UpdatedAt.Before(before) {
+			delete(r.store.data.carts, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Merge moves the items and coupons of the source cart into the target cart and deletes the source cart.
+// Quantities of SKUs present in both carts are summed and capped at maxQuantity;
+// the target cart keeps its own unit price for such lines.
+// It returns an error if either cart does not exist
+// or if the carts hold items in different currencies.
+func (r *CartRepository) Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error {
+	defer r.store.lock(ctx)()
+
+	target, err := r.store.data.cart(targetID)
+	if err != nil {
+		return err
+	}
+	source, err := r.store.data.cart(sourceID)
+	if err != nil {
+		return err
+	}
+	for _, s := range source.items {
+		for _, t := range target.items {
+			if s.Currency != t.Currency {
+				return carterror.ErrCurrencyMismatch
+			}
+		}
+	}
+
+	for _, s := range source.items {
+		if i := indexOfSKU(target.items, s.SKU); i >= 0 {
+			target.items[i].Quantity = min(target.items[i].Quantity+s.Quantity, maxQuantity)
+			continue
+		}
+		s.ID = newID()
+		s.CartID = targetID
+		s.Quantity = min(s.Quantity, maxQuantity)
+		target.items = append(target.items, s)
+	}
+	for _, code := range source.coupons {
+		target.attach(code)
+	}
+
+	delete(r.store.data.carts, sourceID)
+	target.touch(r.store.now())
+	return nil
+}
+
+// containsStatus reports whether statuses contains status.
+func containsStatus(statuses []model.CartStatus, status model.CartStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"fmt"
+)
+
+// CartItemRepository keeps cart items in a Store.
+type CartItemRepository struct {
+	store *Store
+}
+
+// NewCartItemRepository creates a new instance of CartItemRepository.
+func NewCartItemRepository(store *Store) *CartItemRepository {
+	return &CartItemRepository{store: store}
+}
+
+// Create adds a new item to the cart.
+// If the cart already has a line for the same SKU, the quantity of that line
+// is incremented instead, its unit price is refreshed and merged is reported as true;
+// increments beyond maxQuantity are rejected.
+// Items priced in a currency different from the rest of the cart are rejected.
+func (r *CartItemRepository) Create(ctx context.Context, item *model.CartItem, maxQuantity int) (bool, error) {
+	defer r.store.lock(ctx)()
+
+	record, err := r.store.data.cart(item.CartID)
+	if err != nil {
+		return false, fmt.Errorf("Create: %w", err)
+	}
+	for _, existing := range record.items {
+		if existing.Currency != item.Currency {
+			return false, carterror.ErrCurrencyMismatch
+		}
+	}
+	if _, ok := r.store.data.products[item.SKU]; !ok {
+		return false, carterror.ErrProductDoesNotExist
+	}
+
+	merged := false
+	if i := indexOfSKU(record.items, item.SKU); i >= 0 {
+		line := &record.items[i]
+		if line.Quantity+item.Quantity > maxQuantity {
+			return false, carterror.ErrQuantityTooLarge
+		}
+		line.Quantity += item.Quantity
+		line.UnitPrice = item.UnitPrice
+		line.Currency = item.Currency
+		item.ID = line.ID
+		item.Quantity = line.Quantity
+		merged = true
+	} else {
+		item.ID = newID()
+		record.items = append(record.items, model.CartItem{
+			ID:        item.ID,
+			CartID:    item.CartID,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Currency:  item.Currency,
+		})
+	}
+	record.touch(r.store.now())
+	return merged, nil
+}
+
+// Delete removes a cart item by its ID and cart ID.
+// It returns an error if the cart or the item does not exist.
+func (r *CartItemRepository) Delete(ctx context.Context, cartID, cartItemID string) error {
+	defer r.store.lock(ctx)()
+
+	record, err := r.store.data.cart(cartID)
+	if err != nil {
+		return err
+	}
+	i := indexOfItem(record.items, cartItemID)
+	if i < 0 {
+		return carterror.ErrCartItemDoesNotExist
+	}
+	record.items = append(record.items[:i], record.items[i+1:]...)
+	record.touch(r.store.now())
+	return nil
+}
+
+// UpdateQuantity sets the quantity of a cart item identified by its ID and cart ID
+// and returns the updated item.
+// It returns an error if the cart or the item does not exist.
+func (r *CartItemRepository) UpdateQuantity(ctx context.Context, cartID, cartItemID string, quantity int) (*model.CartItem, error) {
+	defer r.store.lock(ctx)()
+
+	record, err := r.store.data.cart(cartID)
+	if err != nil {
+		return nil, err
+	}
+	i := indexOfItem(record.items, cartItemID)
+	if i < 0 {
+		return nil, carterror.ErrCartItemDoesNotExist
+	}
+	record.items[i].Quantity = quantity
+	record.touch(r.store.now())
+	item := record.items[i]
+	return &item, nil
+}
+
+// indexOfSKU returns the index of the line with the given SKU, or -1.
+func indexOfSKU(items []model.CartItem, sku string) int {
+	for i, item := range items {
+		if item.SKU == sku {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexOfItem returns the index of the line with the given ID, or -1.
+func indexOfItem(items []model.CartItem, id string) int {
+	for i, item := range items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
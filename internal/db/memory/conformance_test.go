@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"cart-api/internal/db/memory"
+	"cart-api/internal/storagetest"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		store := memory.NewStore()
+		return storagetest.Backend{
+			Carts:    memory.NewCartRepository(store),
+			Items:    memory.NewCartItemRepository(store),
+			Products: memory.NewProductRepository(store),
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"sort"
+)
+
+// CouponRepository keeps coupons and their attachment to carts in a Store.
+type CouponRepository struct {
+	store *Store
+}
+
+// NewCouponRepository creates a new instance of CouponRepository.
+func NewCouponRepository(store *Store) *CouponRepository {
+	return &CouponRepository{store: store}
+}
+
+// Get retrieves a coupon by its code.
+func (r *CouponRepository) Get(ctx context.Context, code string) (*model.Coupon, error) {
+	defer r.store.lock(ctx)()
+
+	coupon, ok := r.store.data.coupons[code]
+	if !ok {
+		return nil, carterror.ErrCouponDoesNotExist
+	}
+	return &coupon, nil
+}
+
+// ListForCart retrieves the coupons attached to the cart with the given ID, ordered by code.
+func (r *CouponRepository) ListForCart(ctx context.Context, cartID string) ([]model.Coupon, error) {
+	defer r.store.lock(ctx)()
+
+	coupons := []model.Coupon{}
+	record, ok := r.store.data.carts[cartID]
+	if !ok {
+		return coupons, nil
+	}
+	for _, code := range record.coupons {
+		if coupon, ok := r.store.data.coupons[code]; ok {
+			coupons = append(coupons, coupon)
+		}
+	}
+	return coupons, nil
+}
+
+// Attach attaches a coupon to the cart. Attaching a coupon twice has no effect.
+func (r *CouponRepository) Attach(ctx context.Context, cartID, code string) error {
+	defer r.store.lock(ctx)()
+
+	record, err := r.store.data.cart(cartID)
+	if err != nil {
+		return err
+	}
+	if _, ok := r.store.data.coupons[code]; !ok {
+		return carterror.ErrCouponDoesNotExist
+	}
+	record.attach(code)
+	record.touch(r.store.now())
+	return nil
+}
+
+// Detach removes a coupon from the cart.
+// It returns an error if the coupon is not attached to the cart.
+func (r *CouponRepository) Detach(ctx context.Context, cartID, code string) error {
+	defer r.store.lock(ctx)()
+
+	record, err := r.store.data.cart(cartID)
+	if err != nil {
+		return err
+	}
+	i := sort.SearchStrings(record.coupons, code)
+	if i == len(record.coupons) || record.coupons[i] != code {
+		return carterror.ErrCouponNotApplied
+	}
+	record.coupons = append(record.coupons[:i], record.coupons[i+1:]...)
+	record.touch(r.store.now())
+	return nil
+}
+
+// attach adds the coupon code to the cart, keeping the codes sorted and unique.
+func (r *cartRecord) attach(code string) {
+	i := sort.SearchStrings(r.coupons, code)
+	if i < len(r.coupons) && r.coupons[i] == code {
+		return
+	}
+	r.coupons = append(r.coupons, "")
+	copy(r.coupons[i+1:], r.coupons[i:])
+	r.coupons[i] = code
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"cart-api/internal/model"
+	"context"
+	"time"
+)
+
+// keyRecord is a request stored under an idempotency key.
+type keyRecord struct {
+	record      model.IdempotencyRecord
+	lockedUntil time.Time
+	expiresAt   time.Time
+}
+
+// IdempotencyRepository keeps idempotency keys in a Store.
+type IdempotencyRepository struct {
+	store *Store
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository.
+func NewIdempotencyRepository(store *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{store: store}
+}
+
+// Reserve claims the key within scope for a request with the given hash until expiresAt.
+// The request holds the key until lockedUntil; if it has not completed by then, a retry of the same request takes it over.
+// A key whose previous reservation has expired is claimed again.
+// If the key is taken, it returns the stored record and false.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*model.IdempotencyRecord, bool, error) {
+	defer r.store.lock(ctx)()
+
+	id := scope + "\x00" + key
+	now := r.store.now()
+	if existing, ok := r.store.data.keys[id]; ok && existing.expiresAt.After(now) {
+		lapsed := existing.record.Response == nil && !existing.lockedUntil.After(now) && existing.record.RequestHash == requestHash
+		if !lapsed {
+			record := existing.record
+			return &record, false, nil
+		}
+	}
+	r.store.data.keys[id] = keyRecord{record: model.IdempotencyRecord{RequestHash: requestHash}, lockedUntil: lockedUntil, expiresAt: expiresAt}
+	return nil, true, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error {
+	defer r.store.lock(ctx)()
+
+	id := scope + "\x00" + key
+	existing, ok := r.store.data.keys[id]
+	if !ok {
+		return nil
+	}
+	existing.record.Response = &response
+	r.store.data.keys[id] = existing
+	return nil
+}
+
+// Release removes the reservation of a key whose request has not completed.
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	defer r.store.lock(ctx)()
+
+	id := scope + "\x00" + key
+	if existing, ok := r.store.data.keys[id]; ok && existing.record.Response == nil {
+		delete(r.store.data.keys, id)
+	}
+	return nil
+}
+
+// PurgeExpired deletes the keys that expired before the given time and returns their number.
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	defer r.store.lock(ctx)()
+
+	var purged int64
+	for id, existing := range r.store.data.keys {
+		if existing.expiresAt.Before(before) {
+			delete(r.store.data.keys, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,32 @@
+package memory_test
+
+import (
+	"cart-api/internal/db/memory"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepository_TakesOverLapsedLease(t *testing.T) {
+	repo := memory.NewIdempotencyRepository(memory.NewStore())
+	ctx := context.Background()
+
+	_, reserved, err := repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(-time.Second), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	record, reserved, err := repo.Reserve(ctx, "user:1", "key", "other", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, reserved, "only a retry of the same request takes over the lease")
+	assert.Equal(t, "hash", record.RequestHash)
+
+	_, reserved, err = repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	_, reserved, err = repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, reserved, "the new lease has not lapsed")
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+)
+
+// OrderRepository keeps orders in a Store.
+type OrderRepository struct {
+	store *Store
+}
+
+// NewOrderRepository creates a new instance of OrderRepository.
+func NewOrderRepository(store *Store) *OrderRepository {
+	return &OrderRepository{store: store}
+}
+
+// Create stores the order and counts a use of every applied coupon, all at once.
+// It returns an error if the cart does not exist
+// or one of the coupons has reached its usage limit in the meantime.
+func (r *OrderRepository) Create(ctx context.Context, order *model.Order) error {
+	defer r.store.lock(ctx)()
+
+	if _, err := r.store.data.cart(order.CartID); err != nil {
+		return err
+	}
+	for _, discount := range order.Discounts {
+		coupon, ok := r.store.data.coupons[discount.Code]
+		if !ok || (coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit) {
+			return carterror.ErrCouponExhausted
+		}
+	}
+
+	for _, discount := range order.Discounts {
+		coupon := r.store.data.coupons[discount.Code]
+		coupon.TimesUsed++
+		r.store.data.coupons[discount.Code] = coupon
+	}
+	order.ID = newID()
+	order.CreatedAt = r.store.now()
+	stored := *order
+	stored.Lines = append([]model.OrderLine(nil), order.Lines...)
+	stored.Discounts = append([]model.AppliedDiscount(nil), order.Discounts...)
+	r.store.data.orders[order.CartID] = stored
+	return nil
+}
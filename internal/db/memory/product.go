@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"sort"
+)
+
+// ProductRepository keeps the product catalog in a Store.
+type ProductRepository struct {
+	store *Store
+}
+
+// NewProductRepository creates a new instance of ProductRepository.
+func NewProductRepository(store *Store) *ProductRepository {
+	return &ProductRepository{store: store}
+}
+
+// Create adds a new product to the catalog.
+// It returns an error if a product with the same SKU already exists.
+func (r *ProductRepository) Create(ctx context.Context, product *model.Product) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.data.products[product.SKU]; ok {
+		return carterror.ErrProductAlreadyExists
+	}
+	r.store.data.products[product.SKU] = *product
+	return nil
+}
+
+// Get retrieves a product by its SKU.
+func (r *ProductRepository) Get(ctx context.Context, sku string) (*model.Product, error) {
+	defer r.store.lock(ctx)()
+
+	product, ok := r.store.data.products[sku]
+	if !ok {
+		return nil, carterror.ErrProductDoesNotExist
+	}
+	return &product, nil
+}
+
+// List retrieves all products ordered by SKU.
+func (r *ProductRepository) List(ctx context.Context) ([]model.Product, error) {
+	defer r.store.lock(ctx)()
+
+	products := make([]model.Product, 0, len(r.store.data.products))
+	for _, product := range r.store.data.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].SKU < products[j].SKU })
+	return products, nil
+}
+
+// Update overwrites the name, price, currency and active flag of an existing product.
+func (r *ProductRepository) Update(ctx context.Context, product *model.Product) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.data.products[product.SKU]; !ok {
+		return carterror.ErrProductDoesNotExist
+	}
+	r.store.data.products[product.SKU] = *product
+	return nil
+}
+
+// Delete removes a product by its SKU.
+// Products that are still referenced by cart items or coupons cannot be deleted; deactivate them instead.
+func (r *ProductRepository) Delete(ctx context.Context, sku string) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.data.products[sku]; !ok {
+		return carterror.ErrProductDoesNotExist
+	}
+	for _, record := range r.store.data.carts {
+		if indexOfSKU(record.items, sku) >= 0 {
+			return carterror.ErrProductInUse
+		}
+	}
+	for _, coupon := range r.store.data.coupons {
+		if coupon.SKU == sku {
+			return carterror.ErrProductInUse
+		}
+	}
+	delete(r.store.data.products, sku)
+	return nil
+}
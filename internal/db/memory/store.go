@@ -0,0 +1,167 @@
+// Package memory implements the storage interfaces of the service package in memory,
+// for local development and tests that should not need Postgres.
+// It behaves like the postgres package, which is verified by the conformance suite in storagetest.
+package memory
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store holds the data shared by the repositories of this package.
+// All access is serialized by a single mutex; a unit of work holds it until it ends.
+type Store struct {
+	mu   sync.Mutex
+	data *data
+	now  func() time.Time
+}
+
+// data is the content of a Store, kept separate so that it can be copied for rollbacks.
+type data struct {
+	carts    map[string]*cartRecord
+	products map[string]model.Product
+	coupons  map[string]model.Coupon
+	orders   map[string]model.Order
+	keys     map[string]keyRecord
+}
+
+// cartRecord is a cart with the rows that belong to it.
+type cartRecord struct {
+	cart        model.Cart
+	owner       model.Owner
+	ttl         time.Duration
+	items       []model.CartItem
+	transitions []model.StatusTransition
+	coupons     []string
+}
+
+// NewStore creates a new empty Store.
+func NewStore() *Store {
+	return &Store{
+		data: &data{
+			carts:    map[string]*cartRecord{},
+			products: map[string]model.Product{},
+			coupons:  map[string]model.Coupon{},
+			orders:   map[string]model.Order{},
+			keys:     map[string]keyRecord{},
+		},
+		now: time.Now,
+	}
+}
+
+// AddCoupon stores a coupon, replacing any coupon with the same code.
+// Coupons have no management API, so they are provisioned directly.
+func (s *Store) AddCoupon(coupon model.Coupon) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.coupons[coupon.Code] = coupon
+}
+
+// txKey is the context key of the Store whose unit of work is running.
+type txKey struct{}
+
+// lock acquires the store for a single repository call and returns the function that releases it.
+// Calls made within a unit of work already hold the store.
+func (s *Store) lock(ctx context.Context) func() {
+	if store, ok := ctx.Value(txKey{}).(*Store); ok && store == s {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// clone returns a deep copy of the data.
+func (d *data) clone() *data {
+	c := &data{
+		carts:    make(map[string]*cartRecord, len(d.carts)),
+		products: make(map[string]model.Product, len(d.products)),
+		coupons:  make(map[string]model.Coupon, len(d.coupons)),
+		orders:   make(map[string]model.Order, len(d.orders)),
+		keys:     make(map[string]keyRecord, len(d.keys)),
+	}
+	for id, record := range d.carts {
+		c.carts[id] = &cartRecord{
+			cart:        record.cart,
+			owner:       record.owner,
+			ttl:         record.ttl,
+			items:       append([]model.CartItem(nil), record.items...),
+			transitions: append([]model.StatusTransition(nil), record.transitions...),
+			coupons:     append([]string(nil), record.coupons...),
+		}
+	}
+	for sku, product := range d.products {
+		c.products[sku] = product
+	}
+	for code, coupon := range d.coupons {
+		c.coupons[code] = coupon
+	}
+	for cartID, order := range d.orders {
+		c.orders[cartID] = order
+	}
+	for key, record := range d.keys {
+		c.keys[key] = record
+	}
+	return c
+}
+
+// Transactor runs units of work on a Store.
+type Transactor struct {
+	store *Store
+}
+
+// NewTransactor creates a new instance of Transactor.
+func NewTransactor(store *Store) *Transactor {
+	return &Transactor{store: store}
+}
+
+// WithinTx runs fn while holding the store, so that units of work are serialized.
+// If fn fails, every change it made is discarded.
+// Nested calls join the unit of work that is already running.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if store, ok := ctx.Value(txKey{}).(*Store); ok && store == t.store {
+		return fn(ctx)
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	snapshot := t.store.data.clone()
+	if err := fn(context.WithValue(ctx, txKey{}, t.store)); err != nil {
+		t.store.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// cart returns the cart with the given ID, or an error if it does not exist.
+func (d *data) cart(id string) (*cartRecord, error) {
+	record, ok := d.carts[id]
+	if !ok {
+		return nil, carterror.ErrCartDoesNotExist
+	}
+	return record, nil
+}
+
+// touch marks the cart as changed by incrementing its version and extends its expiry
+// by the lifetime it was created with.
+func (r *cartRecord) touch(now time.Time) {
+	r.cart.ExpiresAt = now.Add(r.ttl)
+	r.cart.UpdatedAt = now
+	r.cart.Version++
+}
+
+// newID generates a random version 4 UUID.
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
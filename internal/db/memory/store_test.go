@@ -0,0 +1,54 @@
+package memory_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/db/memory"
+	"cart-api/internal/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithinTx_RollsBackOnError(t *testing.T) {
+	store := memory.NewStore()
+	carts := memory.NewCartRepository(store)
+	transactor := memory.NewTransactor(store)
+
+	cart, err := carts.Create(context.Background(), model.Owner{Kind: model.OwnerUser, ID: "user-1"}, time.Hour)
+	assert.NoError(t, err)
+
+	failure := errors.New("failure")
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := carts.Delete(ctx, cart.ID); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	_, err = carts.Get(context.Background(), cart.ID)
+	assert.NoError(t, err)
+}
+
+func TestWithinTx_Commits(t *testing.T) {
+	store := memory.NewStore()
+	carts := memory.NewCartRepository(store)
+	transactor := memory.NewTransactor(store)
+
+	cart, err := carts.Create(context.Background(), model.Owner{Kind: model.OwnerUser, ID: "user-1"}, time.Hour)
+	assert.NoError(t, err)
+
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := carts.Lock(ctx, cart.ID); err != nil {
+			return err
+		}
+		return carts.Delete(ctx, cart.ID)
+	})
+	assert.NoError(t, err)
+
+	_, err = carts.Get(context.Background(), cart.ID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
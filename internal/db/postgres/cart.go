@@ -6,8 +6,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // CartRepository provides methods to interact with the carts table in the database.
@@ -20,24 +22,56 @@ func NewCartRepository(db *sqlx.DB) *CartRepository {
 	return &CartRepository{db: db}
 }
 
-// Create inserts a new cart into the database and returns the created cart.
+// Create inserts a new cart owned by owner that expires after ttl into the database and returns the created cart.
 // The cart is initialized with an empty list of items.
-func (r *CartRepository) Create(ctx context.Context) (*model.Cart, error) {
+func (r *CartRepository) Create(ctx context.Context, owner model.Owner, ttl time.Duration) (*model.Cart, error) {
 	var cart model.Cart
-	query := `INSERT INTO carts DEFAULT VALUES RETURNING id`
-	err := r.db.QueryRowxContext(ctx, query).Scan(&cart.ID)
+	query := `INSERT INTO carts (owner_kind, owner_id, ttl, expires_at) VALUES ($1, $2, make_interval(secs => $3), now() + make_interval(secs => $3))
+		RETURNING id, status, version, created_at, updated_at, expires_at`
+	err := conn(ctx, r.db).QueryRowxContext(ctx, query, owner.Kind, owner.ID, ttl.Seconds()).Scan(&cart.ID, &cart.Status, &cart.Version, &cart.CreatedAt, &cart.UpdatedAt, &cart.ExpiresAt)
 	if err != nil {
 		return nil, carterror.ErrFailedPostgresOpperation
 	}
 	cart.Items = []model.CartItem{}
+	cart.Transitions = []model.StatusTransition{}
+	return &cart, nil
+}
+
+// GetOwner retrieves the owner of a cart by its ID.
+// Carts created before ownership was introduced have an empty owner.
+func (r *CartRepository) GetOwner(ctx context.Context, id string) (model.Owner, error) {
+	var owner model.Owner
+	query := `SELECT COALESCE(owner_kind, '') AS owner_kind, COALESCE(owner_id, '') AS owner_id FROM carts WHERE id = $1`
+	err := conn(ctx, r.db).GetContext(ctx, &owner, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Owner{}, carterror.ErrCartDoesNotExist
+	}
+	if err != nil {
+		return model.Owner{}, carterror.ErrFailedPostgresOpperation
+	}
+	return owner, nil
+}
+
+// Lock locks the cart row until the end of the unit of work running in ctx
+// and returns the cart with its status and version but without its items.
+func (r *CartRepository) Lock(ctx context.Context, id string) (*model.Cart, error) {
+	var cart model.Cart
+	query := `SELECT id, status, version FROM carts WHERE id = $1 FOR UPDATE`
+	err := conn(ctx, r.db).GetContext(ctx, &cart, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, carterror.ErrCartDoesNotExist
+	}
+	if err != nil {
+		return nil, carterror.ErrFailedPostgresOpperation
+	}
 	return &cart, nil
 }
 
 // Get retrieves a cart by its ID, including all associated cart items.
 func (r *CartRepository) Get(ctx context.Context, id string) (*model.Cart, error) {
 	var cart model.Cart
-	query := `SELECT id FROM carts WHERE id = $1`
-	err := r.db.GetContext(ctx, &cart, query, id)
+	query := `SELECT id, status, version, created_at, updated_at, expires_at, checked_out_at FROM carts WHERE id = $1`
+	err := conn(ctx, r.db).GetContext(ctx, &cart, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, carterror.ErrCartDoesNotExist
 	}
@@ -45,8 +79,15 @@ func (r *CartRepository) Get(ctx context.Context, id string) (*model.Cart, error
 		return nil, carterror.ErrFailedToRetrieveCart
 	}
 
-	itemsQuery := `SELECT id, cart_id, product, quantity FROM cart_items WHERE cart_id = $1`
-	err = r.db.SelectContext(ctx, &cart.Items, itemsQuery, id)
+	transitionsQuery := `SELECT from_status, to_status, changed_at FROM cart_status_transitions WHERE cart_id = $1 ORDER BY changed_at, id`
+	cart.Transitions = []model.StatusTransition{}
+	err = conn(ctx, r.db).SelectContext(ctx, &cart.Transitions, transitionsQuery, id)
+	if err != nil {
+		return nil, carterror.ErrFailedToRetrieveCart
+	}
+
+	itemsQuery := `SELECT id, cart_id, sku, quantity, unit_price, currency FROM cart_items WHERE cart_id = $1`
+	err = conn(ctx, r.db).SelectContext(ctx, &cart.Items, itemsQuery, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, carterror.ErrCartDoesNotExist
 	}
@@ -55,3 +96,191 @@ func (r *CartRepository) Get(ctx context.Context, id string) (*model.Cart, error
 	}
 	return &cart, nil
 }
+
+// Delete removes a cart by its ID.
+// The cart items are removed by the ON DELETE CASCADE constraint on cart_items.
+func (r *CartRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM carts WHERE id = $1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	if affected == 0 {
+		return carterror.ErrCartDoesNotExist
+	}
+	return nil
+}
+
+// Clear removes all items from the cart with the given ID, keeping the cart itself.
+// The cart is locked for the duration of the change so that items cannot be added to it concurrently.
+func (r *CartRepository) Clear(ctx context.Context, id string) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, id); err != nil {
+			return err
+		}
+
+		query := `DELETE FROM cart_items WHERE cart_id = $1`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return carterror.ErrFailedPostgresOpperation
+		}
+		return touchCart(ctx, tx, id)
+	})
+}
+
+// SetStatus changes the status of the cart from the given status to another one
+// and records the transition. It fails if the cart is no longer in the from status.
+func (r *CartRepository) SetStatus(ctx context.Context, id string, from, to model.CartStatus) error {
+	return setCartStatus(ctx, conn(ctx, r.db), id, from, to)
+}
+
+// checkCartExists verifies that the cart exists.
+// Within a transaction, the cart row stays locked until the transaction ends.
+func checkCartExists(ctx context.Context, q sqlx.QueryerContext, cartID string) error {
+	var id string
+	query := `SELECT id FROM carts WHERE id = $1 FOR UPDATE`
+	err := q.QueryRowxContext(ctx, query, cartID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return carterror.ErrCartDoesNotExist
+	}
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	return nil
+}
+
+// setCartStatus atomically moves the cart from one status to another and records the transition.
+// Moving the cart to the checked out status also records the checkout time.
+func setCartStatus(ctx context.Context, q sqlx.ExtContext, id string, from, to model.CartStatus) error {
+	query := `WITH updated AS (
+			UPDATE carts SET status = $3, version = version + 1, updated_at = now(),
+				checked_out_at = CASE WHEN $3 = 'checked_out' THEN now() ELSE checked_out_at END
+			WHERE id = $1 AND status = $2 RETURNING id
+		)
+		INSERT INTO cart_status_transitions (cart_id, from_status, to_status)
+		SELECT id, $2, $3 FROM updated`
+	res, err := q.ExecContext(ctx, query, id, from, to)
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	query = `SELECT EXISTS(SELECT 1 FROM carts WHERE id = $1)`
+	if err := q.QueryRowxContext(ctx, query, id).Scan(&exists); err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	if !exists {
+		return carterror.ErrCartDoesNotExist
+	}
+	return carterror.ErrInvalidStatusTransition
+}
+
+// ExpireStale moves every cart in one of the given statuses whose expiry time has passed
+// to the expired status, records the transitions and returns the number of expired carts.
+func (r *CartRepository) ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error) {
+	statuses := make([]string, 0, len(from))
+	for _, status := range from {
+		statuses = append(statuses, string(status))
+	}
+	query := `WITH expired AS (
+			UPDATE carts c SET status = 'expired', version = c.version + 1, updated_at = $1
+			FROM (SELECT id, status FROM carts WHERE status = ANY($2) AND expires_at <= $1 FOR UPDATE SKIP LOCKED) stale
+			WHERE c.id = stale.id
+			RETURNING c.id, stale.status
+		)
+		INSERT INTO cart_status_transitions (cart_id, from_status, to_status, changed_at)
+		SELECT id, status, 'expired', $1 FROM expired`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, now, pq.Array(statuses))
+	if err != nil {
+		return 0, carterror.ErrFailedPostgresOpperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, carterror.ErrFailedPostgresOpperation
+	}
+	return affected, nil
+}
+
+// PurgeExpired deletes the carts that expired before the given time and returns their number.
+// Their items, coupons and transitions are removed by the ON DELETE CASCADE constraints.
+func (r *CartRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM carts WHERE status = 'expired' AND updated_at < $1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, carterror.ErrFailedPostgresOpperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, carterror.ErrFailedPostgresOpperation
+	}
+	return affected, nil
+}
+
+// touchCart marks the cart as changed by incrementing its version and extends its expiry by the lifetime it was created with.
+func touchCart(ctx context.Context, q sqlx.ExecerContext, id string) error {
+	query := `UPDATE carts SET updated_at = now(), expires_at = now() + ttl, version = version + 1 WHERE id = $1`
+	if _, err := q.ExecContext(ctx, query, id); err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	return nil
+}
+
+// Merge moves the items and coupons of the source cart into the target cart
+// and deletes the source cart, all within a single transaction.
+// Quantities of SKUs present in both carts are summed and capped at maxQuantity;
+// the target cart keeps its own unit price for such lines.
+// It returns an error if either cart does not exist
+// or if the carts hold items in different currencies.
+func (r *CartRepository) Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, targetID); err != nil {
+			return err
+		}
+		if err := checkCartExists(ctx, tx, sourceID); err != nil {
+			return err
+		}
+
+		var mixed bool
+		query := `SELECT EXISTS(
+				SELECT 1 FROM cart_items s JOIN cart_items t ON t.cart_id = $1
+				WHERE s.cart_id = $2 AND s.currency <> t.currency
+			)`
+		if err := tx.QueryRowxContext(ctx, query, targetID, sourceID).Scan(&mixed); err != nil {
+			return carterror.ErrFailedPostgresOpperation
+		}
+		if mixed {
+			return carterror.ErrCurrencyMismatch
+		}
+
+		query = `INSERT INTO cart_items (cart_id, sku, quantity, unit_price, currency)
+			SELECT $1, sku, LEAST(quantity, $3), unit_price, currency FROM cart_items WHERE cart_id = $2
+			ON CONFLICT (cart_id, sku) DO UPDATE SET quantity = LEAST(cart_items.quantity + EXCLUDED.quantity, $3)`
+		if _, err := tx.ExecContext(ctx, query, targetID, sourceID, maxQuantity); err != nil {
+			return carterror.ErrFailedPostgresOpperation
+		}
+
+		query = `INSERT INTO cart_coupons (cart_id, code)
+			SELECT $1, code FROM cart_coupons WHERE cart_id = $2
+			ON CONFLICT DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+			return carterror.ErrFailedPostgresOpperation
+		}
+
+		query = `DELETE FROM carts WHERE id = $1`
+		if _, err := tx.ExecContext(ctx, query, sourceID); err != nil {
+			return carterror.ErrFailedPostgresOpperation
+		}
+
+		return touchCart(ctx, tx, targetID)
+	})
+}
@@ -4,8 +4,9 @@ import (
 	"cart-api/internal/carterror"
 	"cart-api/internal/model"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -21,51 +22,92 @@ func NewCartItemRepository(db *sqlx.DB) *CartItemRepository {
 }
 
 // Create inserts a new cart item into the database.
+// If the cart already has a line for the same SKU, the quantity of that line
+// is incremented instead, its unit price is refreshed and merged is reported as true;
+// increments beyond maxQuantity are rejected.
+// Items priced in a currency different from the rest of the cart are rejected.
+// The cart is locked for the duration of the change so that it cannot be deleted or checked out concurrently.
 // It returns an error if the operation fails.
-func (r *CartItemRepository) Create(ctx context.Context, item *model.CartItem) error {
-	exists, err := r.CartExists(ctx, item.CartID)
-	if err != nil {
-		return fmt.Errorf("Create: %w", err)
-	}
-	if !exists {
-		return carterror.ErrCartDoesNotExist
-	}
-	query := `INSERT INTO cart_items (cart_id, product, quantity) VALUES ($1, $2, $3) RETURNING id`
-	var id string
-	err = r.db.QueryRowContext(ctx, query, item.CartID, item.Product, item.Quantity).Scan(&id)
-	if err != nil {
-		return err
-	}
+func (r *CartItemRepository) Create(ctx context.Context, item *model.CartItem, maxQuantity int) (merged bool, err error) {
+	err = inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, item.CartID); err != nil {
+			return fmt.Errorf("Create: %w", err)
+		}
+		var mixed bool
+		query := `SELECT EXISTS(SELECT 1 FROM cart_items WHERE cart_id = $1 AND currency <> $2)`
+		if err := tx.QueryRowxContext(ctx, query, item.CartID, item.Currency).Scan(&mixed); err != nil {
+			return err
+		}
+		if mixed {
+			return carterror.ErrCurrencyMismatch
+		}
 
-	item.ID = id
-	return nil
-}
-
-// CartExists checks if a cart with the given ID exists in the database.
-// It returns a boolean indicating existence and an error if the query fails.
-func (r *CartItemRepository) CartExists(ctx context.Context, cartID string) (bool, error) {
-	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM carts WHERE id = $1)`
-	err := r.db.QueryRowxContext(ctx, query, cartID).Scan(&exists)
+		query = `INSERT INTO cart_items (cart_id, sku, quantity, unit_price, currency) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (cart_id, sku) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity,
+				unit_price = EXCLUDED.unit_price, currency = EXCLUDED.currency
+			WHERE cart_items.quantity + EXCLUDED.quantity <= $6
+			RETURNING id, quantity, (xmax <> 0) AS merged`
+		err := tx.QueryRowxContext(ctx, query, item.CartID, item.SKU, item.Quantity, item.UnitPrice, item.Currency, maxQuantity).Scan(&item.ID, &item.Quantity, &merged)
+		if errors.Is(err, sql.ErrNoRows) {
+			return carterror.ErrQuantityTooLarge
+		}
+		if err != nil {
+			return err
+		}
+		return touchCart(ctx, tx, item.CartID)
+	})
 	if err != nil {
 		return false, err
 	}
-	return exists, nil
+	return merged, nil
 }
 
 // Delete removes a cart item from the database by its ID and cart ID.
+// The cart is locked for the duration of the change.
 // It returns an error if the cart does not exist or the operation fails.
 func (r *CartItemRepository) Delete(ctx context.Context, cartID, cartItemID string) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, cartID); err != nil {
+			return err
+		}
+		query := `DELETE FROM cart_items WHERE id = $1 AND cart_id = $2`
+		res, err := tx.ExecContext(ctx, query, cartItemID, cartID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return carterror.ErrCartItemDoesNotExist
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
 
-	exists, err := r.CartExists(ctx, cartID)
+// UpdateQuantity sets the quantity of a cart item identified by its ID and cart ID
+// and returns the updated item. The cart is locked for the duration of the change.
+// It returns an error if the cart or the item does not exist or the operation fails.
+func (r *CartItemRepository) UpdateQuantity(ctx context.Context, cartID, cartItemID string, quantity int) (*model.CartItem, error) {
+	var item model.CartItem
+	err := inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, cartID); err != nil {
+			return err
+		}
+
+		query := `UPDATE cart_items SET quantity = $1 WHERE id = $2 AND cart_id = $3 RETURNING id, cart_id, sku, quantity, unit_price, currency`
+		err := tx.QueryRowxContext(ctx, query, quantity, cartItemID, cartID).StructScan(&item)
+		if errors.Is(err, sql.ErrNoRows) {
+			return carterror.ErrCartItemDoesNotExist
+		}
+		if err != nil {
+			return err
+		}
+		return touchCart(ctx, tx, cartID)
+	})
 	if err != nil {
-		return err
-	}
-	if !exists {
-		return carterror.ErrNotFound
+		return nil, err
 	}
-	log.Println("id : ", cartItemID, "cart_id : ", cartID)
-	query := `DELETE FROM cart_items WHERE id = $1 AND cart_id = $2`
-	_, err = r.db.ExecContext(ctx, query, cartItemID, cartID)
-	return err
+	return &item, nil
 }
@@ -1,6 +1,7 @@
 package postgres_test
 
 import (
+	"cart-api/internal/carterror"
 	"cart-api/internal/db/postgres"
 	"cart-api/internal/model"
 	"context"
@@ -22,17 +23,33 @@ func TestCreateCartItem(t *testing.T) {
 	repo := postgres.NewCartItemRepository(sqlxDB)
 
 	item := &model.CartItem{
-		CartID:   "cart-id",
-		Product:  "product1",
-		Quantity: 2,
+		CartID:    "cart-id",
+		SKU:       "product1",
+		Quantity:  2,
+		UnitPrice: 150,
+		Currency:  "USD",
 	}
 
-	mock.ExpectQuery(`INSERT INTO cart_items \(cart_id, product, quantity\) VALUES \(\$1, \$2, \$3\) RETURNING id`).
-		WithArgs(item.CartID, item.Product, item.Quantity).
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("item-id"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM cart_items WHERE cart_id = \$1 AND currency <> \$2\)`).
+		WithArgs("cart-id", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	mock.ExpectQuery(`INSERT INTO cart_items \(cart_id, sku, quantity, unit_price, currency\) VALUES \(\$1, \$2, \$3, \$4, \$5\)\s+ON CONFLICT \(cart_id, sku\) DO UPDATE`).
+		WithArgs(item.CartID, item.SKU, item.Quantity, item.UnitPrice, item.Currency, 999).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity", "merged"}).AddRow("item-id", 2, false))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
-	err = repo.Create(context.Background(), item)
+	merged, err := repo.Create(context.Background(), item, 999)
 	assert.NoError(t, err)
+	assert.False(t, merged)
 	assert.Equal(t, "item-id", item.ID)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -40,7 +57,7 @@ func TestCreateCartItem(t *testing.T) {
 	}
 }
 
-func TestCartExists(t *testing.T) {
+func TestCreateCartItem_MergesDuplicateProduct(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to open mock database: %s", err)
@@ -50,20 +67,43 @@ func TestCartExists(t *testing.T) {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	repo := postgres.NewCartItemRepository(sqlxDB)
 
-	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM carts WHERE id = \$1\)`).
+	item := &model.CartItem{
+		CartID:    "cart-id",
+		SKU:       "product1",
+		Quantity:  2,
+		UnitPrice: 150,
+		Currency:  "USD",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
 		WithArgs("cart-id").
-		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM cart_items WHERE cart_id = \$1 AND currency <> \$2\)`).
+		WithArgs("cart-id", "USD").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
-	exists, err := repo.CartExists(context.Background(), "cart-id")
+	mock.ExpectQuery(`INSERT INTO cart_items .* ON CONFLICT \(cart_id, sku\) DO UPDATE SET quantity = cart_items.quantity \+ EXCLUDED.quantity`).
+		WithArgs(item.CartID, item.SKU, item.Quantity, item.UnitPrice, item.Currency, 999).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity", "merged"}).AddRow("existing-id", 5, true))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	merged, err := repo.Create(context.Background(), item, 999)
 	assert.NoError(t, err)
-	assert.True(t, exists)
+	assert.True(t, merged)
+	assert.Equal(t, "existing-id", item.ID)
+	assert.Equal(t, 5, item.Quantity)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
 
-func TestDeleteCartItem_CartNotFound(t *testing.T) {
+func TestCreateCartItem_MergedQuantityTooLarge(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("failed to open mock database: %s", err)
@@ -73,13 +113,79 @@ func TestDeleteCartItem_CartNotFound(t *testing.T) {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	repo := postgres.NewCartItemRepository(sqlxDB)
 
-	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM carts WHERE id = \$1\)`).
+	item := &model.CartItem{CartID: "cart-id", SKU: "product1", Quantity: 600, UnitPrice: 150, Currency: "USD"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
 		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM cart_items WHERE cart_id = \$1 AND currency <> \$2\)`).
+		WithArgs("cart-id", "USD").
 		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
+	mock.ExpectQuery(`ON CONFLICT \(cart_id, sku\) DO UPDATE .* WHERE cart_items.quantity \+ EXCLUDED.quantity <= \$6`).
+		WithArgs(item.CartID, item.SKU, item.Quantity, item.UnitPrice, item.Currency, 999).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity", "merged"}))
+	mock.ExpectRollback()
+
+	_, err = repo.Create(context.Background(), item, 999)
+	assert.ErrorIs(t, err, carterror.ErrQuantityTooLarge)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateCartItem_CurrencyMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartItemRepository(sqlxDB)
+
+	item := &model.CartItem{CartID: "cart-id", SKU: "product1", Quantity: 1, UnitPrice: 100, Currency: "EUR"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM cart_items WHERE cart_id = \$1 AND currency <> \$2\)`).
+		WithArgs("cart-id", "EUR").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = repo.Create(context.Background(), item, 999)
+	assert.ErrorIs(t, err, carterror.ErrCurrencyMismatch)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteCartItem_CartNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartItemRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
 	err = repo.Delete(context.Background(), "cart-id", "item-id")
 	assert.Error(t, err)
-	assert.Equal(t, "cart does not exist", err.Error())
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -96,13 +202,18 @@ func TestDeleteCartItem_Success(t *testing.T) {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	repo := postgres.NewCartItemRepository(sqlxDB)
 
-	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM carts WHERE id = \$1\)`).
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
 		WithArgs("cart-id").
-		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
 
 	mock.ExpectExec(`DELETE FROM cart_items WHERE id = \$1 AND cart_id = \$2`).
 		WithArgs("item-id", "cart-id").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	err = repo.Delete(context.Background(), "cart-id", "item-id")
 	assert.NoError(t, err)
@@ -111,3 +222,94 @@ func TestDeleteCartItem_Success(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestDeleteCartItem_ItemNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartItemRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectExec(`DELETE FROM cart_items WHERE id = \$1 AND cart_id = \$2`).
+		WithArgs("item-id", "cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Delete(context.Background(), "cart-id", "item-id")
+	assert.ErrorIs(t, err, carterror.ErrCartItemDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateQuantity_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartItemRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectQuery(`UPDATE cart_items SET quantity = \$1 WHERE id = \$2 AND cart_id = \$3 RETURNING id, cart_id, sku, quantity, unit_price, currency`).
+		WithArgs(5, "item-id", "cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cart_id", "sku", "quantity", "unit_price", "currency"}).
+			AddRow("item-id", "cart-id", "product1", 5, 150, "USD"))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	item, err := repo.UpdateQuantity(context.Background(), "cart-id", "item-id", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "item-id", item.ID)
+	assert.Equal(t, 5, item.Quantity)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateQuantity_ItemNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartItemRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectQuery(`UPDATE cart_items SET quantity = \$1 WHERE id = \$2 AND cart_id = \$3`).
+		WithArgs(5, "item-id", "cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cart_id", "sku", "quantity"}))
+	mock.ExpectRollback()
+
+	item, err := repo.UpdateQuantity(context.Background(), "cart-id", "item-id", 5)
+	assert.Nil(t, item)
+	assert.ErrorIs(t, err, carterror.ErrCartItemDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -1,9 +1,12 @@
 package postgres_test
 
 import (
+	"cart-api/internal/carterror"
 	"cart-api/internal/db/postgres"
+	"cart-api/internal/model"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -20,13 +23,19 @@ func TestCreateCart(t *testing.T) {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	repo := postgres.NewCartRepository(sqlxDB)
 
-	mock.ExpectQuery(`INSERT INTO carts DEFAULT VALUES RETURNING id`).
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+	now := time.Now()
+	owner := model.Owner{Kind: model.OwnerSession, ID: "session-hash"}
+	mock.ExpectQuery(`INSERT INTO carts \(owner_kind, owner_id, ttl, expires_at\) VALUES \(\$1, \$2, make_interval\(secs => \$3\), now\(\) \+ make_interval\(secs => \$3\)\)`).
+		WithArgs(model.OwnerSession, "session-hash", float64(3600)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "version", "created_at", "updated_at", "expires_at"}).
+			AddRow("cart-id", "active", 1, now, now, now.Add(time.Hour)))
 
-	cart, err := repo.Create(context.Background())
+	cart, err := repo.Create(context.Background(), owner, time.Hour)
 	assert.NoError(t, err)
 	assert.NotNil(t, cart)
 	assert.Equal(t, "cart-id", cart.ID)
+	assert.Equal(t, model.CartActive, cart.Status)
+	assert.Equal(t, now.Add(time.Hour), cart.ExpiresAt)
 	assert.Empty(t, cart.Items)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -44,20 +53,320 @@ func TestGetCart(t *testing.T) {
 	sqlxDB := sqlx.NewDb(db, "sqlmock")
 	repo := postgres.NewCartRepository(sqlxDB)
 
-	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1`).
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, status, version, created_at, updated_at, expires_at, checked_out_at FROM carts WHERE id = \$1`).
 		WithArgs("cart-id").
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "version", "created_at", "updated_at", "expires_at"}).
+			AddRow("cart-id", "active", 1, now, now, now.Add(time.Hour)))
+
+	mock.ExpectQuery(`SELECT from_status, to_status, changed_at FROM cart_status_transitions WHERE cart_id = \$1`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"from_status", "to_status", "changed_at"}).
+			AddRow("locked", "active", time.Now()))
 
-	mock.ExpectQuery(`SELECT id, cart_id, product, quantity FROM cart_items WHERE cart_id = \$1`).
+	mock.ExpectQuery(`SELECT id, cart_id, sku, quantity, unit_price, currency FROM cart_items WHERE cart_id = \$1`).
 		WithArgs("cart-id").
-		WillReturnRows(sqlmock.NewRows([]string{"id", "cart_id", "product", "quantity"}).
-			AddRow("item-id", "cart-id", "product1", 2))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cart_id", "sku", "quantity", "unit_price", "currency"}).
+			AddRow("item-id", "cart-id", "product1", 2, 150, "USD"))
 
 	cart, err := repo.Get(context.Background(), "cart-id")
 	assert.NoError(t, err)
 	assert.NotNil(t, cart)
 	assert.Equal(t, "cart-id", cart.ID)
-	assert.Equal(t, "product1", cart.Items[0].Product)
+	assert.Equal(t, model.CartActive, cart.Status)
+	assert.Equal(t, int64(1), cart.Version)
+	assert.Equal(t, model.CartLocked, cart.Transitions[0].From)
+	assert.Equal(t, "product1", cart.Items[0].SKU)
+	assert.Equal(t, int64(150), cart.Items[0].UnitPrice)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetCartOwner(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT COALESCE\(owner_kind, ''\) AS owner_kind, COALESCE\(owner_id, ''\) AS owner_id FROM carts WHERE id = \$1`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"owner_kind", "owner_id"}).AddRow("user", "user-1"))
+
+	owner, err := repo.GetOwner(context.Background(), "cart-id")
+	assert.NoError(t, err)
+	assert.Equal(t, model.Owner{Kind: model.OwnerUser, ID: "user-1"}, owner)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteCart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectExec(`DELETE FROM carts WHERE id = \$1`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Delete(context.Background(), "cart-id")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteCart_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectExec(`DELETE FROM carts WHERE id = \$1`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.Delete(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestClearCart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectExec(`DELETE FROM cart_items WHERE cart_id = \$1`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\), expires_at = now\(\) \+ ttl, version = version \+ 1 WHERE id = \$1`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Clear(context.Background(), "cart-id")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestClearCart_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err = repo.Clear(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSetCartStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectExec(`WITH updated AS \(\s*UPDATE carts SET status = \$3, version = version \+ 1, updated_at = now\(\),\s*checked_out_at = CASE WHEN \$3 = 'checked_out' THEN now\(\) ELSE checked_out_at END\s*WHERE id = \$1 AND status = \$2 RETURNING id\s*\)\s*INSERT INTO cart_status_transitions`).
+		WithArgs("cart-id", model.CartActive, model.CartLocked).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.SetStatus(context.Background(), "cart-id", model.CartActive, model.CartLocked)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSetCartStatus_ChangedConcurrently(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectExec(`WITH updated AS`).
+		WithArgs("cart-id", model.CartActive, model.CartLocked).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM carts WHERE id = \$1\)`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	err = repo.SetStatus(context.Background(), "cart-id", model.CartActive, model.CartLocked)
+	assert.ErrorIs(t, err, carterror.ErrInvalidStatusTransition)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExpireStaleCarts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	now := time.Now()
+	mock.ExpectExec(`WITH expired AS \(\s*UPDATE carts c SET status = 'expired'.*FOR UPDATE SKIP LOCKED.*INSERT INTO cart_status_transitions`).
+		WithArgs(now, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	expired, err := repo.ExpireStale(context.Background(), now, []model.CartStatus{model.CartActive, model.CartLocked})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), expired)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurgeExpiredCarts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	before := time.Now().Add(-time.Hour)
+	mock.ExpectExec(`DELETE FROM carts WHERE status = 'expired' AND updated_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	purged, err := repo.PurgeExpired(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMergeCarts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("target").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("target"))
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("source").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("source"))
+	mock.ExpectQuery(`SELECT EXISTS\(\s*SELECT 1 FROM cart_items s JOIN cart_items t`).
+		WithArgs("target", "source").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`INSERT INTO cart_items .* SELECT \$1, sku, LEAST\(quantity, \$3\).* ON CONFLICT \(cart_id, sku\) DO UPDATE SET quantity = LEAST\(cart_items.quantity \+ EXCLUDED.quantity, \$3\)`).
+		WithArgs("target", "source", 999).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO cart_coupons \(cart_id, code\)\s*SELECT \$1, code FROM cart_coupons WHERE cart_id = \$2`).
+		WithArgs("target", "source").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM carts WHERE id = \$1`).
+		WithArgs("source").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("target").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Merge(context.Background(), "target", "source", 999)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMergeCarts_CurrencyMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("target").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("target"))
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("source").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("source"))
+	mock.ExpectQuery(`SELECT EXISTS\(\s*SELECT 1 FROM cart_items s JOIN cart_items t`).
+		WithArgs("target", "source").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	err = repo.Merge(context.Background(), "target", "source", 999)
+	assert.ErrorIs(t, err, carterror.ErrCurrencyMismatch)
 
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("there were unfulfilled expectations: %s", err)
@@ -0,0 +1,46 @@
+package postgres_test
+
+import (
+	"cart-api/internal/db/postgres"
+	"cart-api/internal/storagetest"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+)
+
+// TestConformance runs the storage conformance suite against a real database.
+// It needs TEST_DATABASE_DSN to point to an empty database, whose tables it truncates between tests.
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %s", err)
+	}
+	defer db.Close()
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		t.Fatalf("failed to set dialect: %s", err)
+	}
+	if err := goose.Up(db.DB, "../../../migrations"); err != nil {
+		t.Fatalf("failed to apply migrations: %s", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		_, err := db.Exec(`TRUNCATE carts, cart_items, cart_status_transitions, cart_coupons, coupons, products,
+			orders, order_lines, idempotency_keys CASCADE`)
+		if err != nil {
+			t.Fatalf("failed to truncate tables: %s", err)
+		}
+		return storagetest.Backend{
+			Carts:    postgres.NewCartRepository(db),
+			Items:    postgres.NewCartItemRepository(db),
+			Products: postgres.NewProductRepository(db),
+		}
+	})
+}
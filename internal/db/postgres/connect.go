@@ -3,15 +3,20 @@ package postgres
 import (
 	"cart-api/internal/config"
 	"fmt"
-	"log"
+	"os"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
 )
 
+// migrationsDir is the directory of the Postgres migrations, relative to the working directory.
+const migrationsDir = "migrations"
+
 // Connect connects to database by provided cfg and returns sqlx.DB instance
-// if the connection fails it returns an error
+// if the connection fails it returns an error.
+// It does not apply migrations; see NewMigrator.
 func Connect(cfg config.Config) (*sqlx.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
@@ -27,15 +32,20 @@ func Connect(cfg config.Config) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	if err := goose.SetDialect("postgres"); err != nil {
-		log.Fatalf("Failed to set dialect: %v", err)
-	}
+	return db, nil
+}
 
-	if err := goose.Up(db.Unsafe().DB, "migrations"); err != nil {
-		log.Fatalf("Failed to apply migrations: %v", err)
+// NewMigrator returns a goose provider that applies the migrations of the migrations directory to db.
+// Migrations take a Postgres advisory lock, so that instances deployed at the same time do not run them concurrently.
+// The provider must not be closed, since that closes db.
+func NewMigrator(db *sqlx.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration lock: %w", err)
 	}
-
-	log.Println("Migrations applied successfully!")
-
-	return db, nil
+	p, err := goose.NewProvider(goose.DialectPostgres, db.DB, os.DirFS(migrationsDir), goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return p, nil
 }
@@ -0,0 +1,122 @@
+package postgres_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/db/postgres"
+	"cart-api/internal/model"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+var couponRowColumns = []string{"code", "kind", "percent_off", "amount_off", "currency", "sku",
+	"buy_quantity", "get_quantity", "min_subtotal", "starts_at", "ends_at", "usage_limit", "times_used"}
+
+func TestGetCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCouponRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT code, kind, .* FROM coupons WHERE code = \$1`).
+		WithArgs("TEN").
+		WillReturnRows(sqlmock.NewRows(couponRowColumns).
+			AddRow("TEN", "percentage", 10, 0, "", "", 0, 0, 0, nil, nil, 100, 3))
+
+	coupon, err := repo.Get(context.Background(), "TEN")
+	assert.NoError(t, err)
+	assert.Equal(t, model.CouponPercentage, coupon.Kind)
+	assert.Equal(t, 10, coupon.PercentOff)
+	assert.Nil(t, coupon.EndsAt)
+	assert.Equal(t, 100, *coupon.UsageLimit)
+	assert.Equal(t, 3, coupon.TimesUsed)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetCoupon_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCouponRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT code, kind, .* FROM coupons WHERE code = \$1`).
+		WithArgs("TEN").
+		WillReturnRows(sqlmock.NewRows(couponRowColumns))
+
+	coupon, err := repo.Get(context.Background(), "TEN")
+	assert.Nil(t, coupon)
+	assert.ErrorIs(t, err, carterror.ErrCouponDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAttachCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCouponRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectExec(`INSERT INTO cart_coupons \(cart_id, code\) VALUES \(\$1, \$2\) ON CONFLICT DO NOTHING`).
+		WithArgs("cart-id", "TEN").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Attach(context.Background(), "cart-id", "TEN")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDetachCoupon_NotApplied(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewCouponRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+
+	mock.ExpectExec(`DELETE FROM cart_coupons WHERE cart_id = \$1 AND code = \$2`).
+		WithArgs("cart-id", "TEN").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.Detach(context.Background(), "cart-id", "TEN")
+	assert.ErrorIs(t, err, carterror.ErrCouponNotApplied)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,178 @@
+package postgres_test
+
+import (
+	"cart-api/internal/db/postgres"
+	"cart-api/internal/model"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewIdempotencyRepository(sqlxDB)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(`INSERT INTO idempotency_keys \(scope, key, request_hash, locked_until, expires_at\) VALUES \(\$1, \$2, \$3, \$4, \$5\)`).
+		WithArgs("user:user-1", "key-1", "hash", lockedUntil, expiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("key-1"))
+
+	record, reserved, err := repo.Reserve(context.Background(), "user:user-1", "key-1", "hash", lockedUntil, expiresAt)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Nil(t, record)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReserveIdempotencyKey_Taken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewIdempotencyRepository(sqlxDB)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(`INSERT INTO idempotency_keys`).
+		WithArgs("user:user-1", "key-1", "hash", lockedUntil, expiresAt).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT request_hash, status_code, response_headers, response_body FROM idempotency_keys WHERE scope = \$1 AND key = \$2`).
+		WithArgs("user:user-1", "key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status_code", "response_headers", "response_body"}).
+			AddRow("hash", 201, []byte(`{"Content-Type":"application/json"}`), []byte(`{"id":"cart-id"}`)))
+
+	record, reserved, err := repo.Reserve(context.Background(), "user:user-1", "key-1", "hash", lockedUntil, expiresAt)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, &model.IdempotencyRecord{
+		RequestHash: "hash",
+		Response: &model.RecordedResponse{
+			StatusCode: 201,
+			Header:     map[string]string{"Content-Type": "application/json"},
+			Body:       []byte(`{"id":"cart-id"}`),
+		},
+	}, record)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReserveIdempotencyKey_InProgress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewIdempotencyRepository(sqlxDB)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(`INSERT INTO idempotency_keys`).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT request_hash, status_code, response_headers, response_body FROM idempotency_keys`).
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status_code", "response_headers", "response_body"}).
+			AddRow("hash", nil, nil, nil))
+
+	record, reserved, err := repo.Reserve(context.Background(), "user:user-1", "key-1", "hash", lockedUntil, expiresAt)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "hash", record.RequestHash)
+	assert.Nil(t, record.Response)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCompleteIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewIdempotencyRepository(sqlxDB)
+
+	mock.ExpectExec(`UPDATE idempotency_keys SET status_code = \$3, response_headers = \$4, response_body = \$5`).
+		WithArgs("user:user-1", "key-1", 201, []byte(`{"Content-Type":"application/json"}`), []byte(`{}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Complete(context.Background(), "user:user-1", "key-1", model.RecordedResponse{
+		StatusCode: 201,
+		Header:     map[string]string{"Content-Type": "application/json"},
+		Body:       []byte(`{}`),
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewIdempotencyRepository(sqlxDB)
+
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE scope = \$1 AND key = \$2 AND status_code IS NULL`).
+		WithArgs("user:user-1", "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Release(context.Background(), "user:user-1", "key-1")
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPurgeExpiredIdempotencyKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewIdempotencyRepository(sqlxDB)
+
+	now := time.Now()
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE expires_at < \$1`).
+		WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := repo.PurgeExpired(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
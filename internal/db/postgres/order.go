@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// OrderRepository provides methods to interact with the orders and order_lines tables in the database.
+type OrderRepository struct {
+	db *sqlx.DB
+}
+
+// NewOrderRepository creates a new instance of OrderRepository.
+func NewOrderRepository(db *sqlx.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// Create stores the order together with its lines and counts a use of every applied coupon,
+// all within a single transaction that locks the cart row.
+// It returns an error if the cart does not exist
+// or one of the coupons has reached its usage limit in the meantime.
+func (r *OrderRepository) Create(ctx context.Context, order *model.Order) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, order.CartID); err != nil {
+			return err
+		}
+
+		discounts, err := json.Marshal(order.Discounts)
+		if err != nil {
+			return err
+		}
+		query := `INSERT INTO orders (cart_id, currency, subtotal, discount_total, total, free_shipping, discounts)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+		err = tx.QueryRowxContext(ctx, query, order.CartID, order.Currency, order.Subtotal, order.DiscountTotal,
+			order.Total, order.FreeShipping, discounts).Scan(&order.ID, &order.CreatedAt)
+		if err != nil {
+			return carterror.ErrFailedPostgresOpperation
+		}
+
+		query = `INSERT INTO order_lines (order_id, sku, quantity, unit_price, line_total) VALUES ($1, $2, $3, $4, $5)`
+		for _, line := range order.Lines {
+			if _, err := tx.ExecContext(ctx, query, order.ID, line.SKU, line.Quantity, line.UnitPrice, line.LineTotal); err != nil {
+				return carterror.ErrFailedPostgresOpperation
+			}
+		}
+
+		if len(order.Discounts) > 0 {
+			codes := make([]string, 0, len(order.Discounts))
+			for _, discount := range order.Discounts {
+				codes = append(codes, discount.Code)
+			}
+			query = `UPDATE coupons SET times_used = times_used + 1
+				WHERE code = ANY($1) AND (usage_limit IS NULL OR times_used < usage_limit)`
+			res, err := tx.ExecContext(ctx, query, pq.Array(codes))
+			if err != nil {
+				return carterror.ErrFailedPostgresOpperation
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return carterror.ErrFailedPostgresOpperation
+			}
+			if affected != int64(len(codes)) {
+				return carterror.ErrCouponExhausted
+			}
+		}
+
+		return nil
+	})
+}
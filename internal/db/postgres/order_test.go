@@ -0,0 +1,116 @@
+package postgres_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/db/postgres"
+	"cart-api/internal/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOrder() *model.Order {
+	return &model.Order{
+		CartID:        "cart-id",
+		Lines:         []model.OrderLine{{SKU: "SHOES", Quantity: 2, UnitPrice: 500, LineTotal: 1000}},
+		Currency:      "USD",
+		Subtotal:      1000,
+		Discounts:     []model.AppliedDiscount{{Code: "TEN", Kind: model.CouponPercentage, Amount: 100}},
+		DiscountTotal: 100,
+		Total:         900,
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewOrderRepository(sqlxDB)
+	order := newTestOrder()
+	createdAt := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+	mock.ExpectQuery(`INSERT INTO orders \(cart_id, currency, subtotal, discount_total, total, free_shipping, discounts\)`).
+		WithArgs("cart-id", "USD", int64(1000), int64(100), int64(900), false, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("order-id", createdAt))
+	mock.ExpectExec(`INSERT INTO order_lines \(order_id, sku, quantity, unit_price, line_total\)`).
+		WithArgs("order-id", "SHOES", 2, int64(500), int64(1000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE coupons SET times_used = times_used \+ 1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Create(context.Background(), order)
+	assert.NoError(t, err)
+	assert.Equal(t, "order-id", order.ID)
+	assert.Equal(t, createdAt, order.CreatedAt)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateOrder_CartDoesNotExist(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewOrderRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err = repo.Create(context.Background(), newTestOrder())
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateOrder_CouponExhausted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewOrderRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+	mock.ExpectQuery(`INSERT INTO orders`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("order-id", time.Now()))
+	mock.ExpectExec(`INSERT INTO order_lines`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE coupons SET times_used = times_used \+ 1`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.Create(context.Background(), newTestOrder())
+	assert.ErrorIs(t, err, carterror.ErrCouponExhausted)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// ProductRepository provides methods to interact with the products table in the database.
+type ProductRepository struct {
+	db *sqlx.DB
+}
+
+// NewProductRepository creates a new instance of ProductRepository.
+func NewProductRepository(db *sqlx.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// Create inserts a new product into the database.
+// It returns an error if a product with the same SKU already exists.
+func (r *ProductRepository) Create(ctx context.Context, product *model.Product) error {
+	query := `INSERT INTO products (sku, name, unit_price, currency, active) VALUES ($1, $2, $3, $4, $5)`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, product.SKU, product.Name, product.UnitPrice, product.Currency, product.Active)
+	if isViolation(err, uniqueViolation) {
+		return carterror.ErrProductAlreadyExists
+	}
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	return nil
+}
+
+// Get retrieves a product by its SKU.
+func (r *ProductRepository) Get(ctx context.Context, sku string) (*model.Product, error) {
+	var product model.Product
+	query := `SELECT sku, name, unit_price, currency, active FROM products WHERE sku = $1`
+	err := conn(ctx, r.db).GetContext(ctx, &product, query, sku)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, carterror.ErrProductDoesNotExist
+	}
+	if err != nil {
+		return nil, carterror.ErrFailedPostgresOpperation
+	}
+	return &product, nil
+}
+
+// List retrieves all products ordered by SKU.
+func (r *ProductRepository) List(ctx context.Context) ([]model.Product, error) {
+	products := []model.Product{}
+	query := `SELECT sku, name, unit_price, currency, active FROM products ORDER BY sku`
+	err := conn(ctx, r.db).SelectContext(ctx, &products, query)
+	if err != nil {
+		return nil, carterror.ErrFailedPostgresOpperation
+	}
+	return products, nil
+}
+
+// Update overwrites the name, price, currency and active flag of an existing product.
+func (r *ProductRepository) Update(ctx context.Context, product *model.Product) error {
+	query := `UPDATE products SET name = $2, unit_price = $3, currency = $4, active = $5 WHERE sku = $1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, product.SKU, product.Name, product.UnitPrice, product.Currency, product.Active)
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	if affected == 0 {
+		return carterror.ErrProductDoesNotExist
+	}
+	return nil
+}
+
+// Delete removes a product by its SKU.
+// Products that are still referenced by cart items cannot be deleted; deactivate them instead.
+func (r *ProductRepository) Delete(ctx context.Context, sku string) error {
+	query := `DELETE FROM products WHERE sku = $1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, sku)
+	if isViolation(err, foreignKeyViolation) {
+		return carterror.ErrProductInUse
+	}
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	if affected == 0 {
+		return carterror.ErrProductDoesNotExist
+	}
+	return nil
+}
+
+// isViolation reports whether err is a postgres error with the given SQLSTATE code.
+func isViolation(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -0,0 +1,156 @@
+package postgres_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/db/postgres"
+	"cart-api/internal/model"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewProductRepository(sqlxDB)
+
+	product := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 4999, Currency: "USD", Active: true}
+
+	mock.ExpectExec(`INSERT INTO products \(sku, name, unit_price, currency, active\) VALUES \(\$1, \$2, \$3, \$4, \$5\)`).
+		WithArgs("SHOES-1", "Shoes", int64(4999), "USD", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Create(context.Background(), product)
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateProduct_AlreadyExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewProductRepository(sqlxDB)
+
+	product := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 4999, Currency: "USD", Active: true}
+
+	mock.ExpectExec(`INSERT INTO products`).
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	err = repo.Create(context.Background(), product)
+	assert.ErrorIs(t, err, carterror.ErrProductAlreadyExists)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewProductRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT sku, name, unit_price, currency, active FROM products WHERE sku = \$1`).
+		WithArgs("SHOES-1").
+		WillReturnRows(sqlmock.NewRows([]string{"sku", "name", "unit_price", "currency", "active"}).
+			AddRow("SHOES-1", "Shoes", 4999, "USD", true))
+
+	product, err := repo.Get(context.Background(), "SHOES-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Shoes", product.Name)
+	assert.Equal(t, int64(4999), product.UnitPrice)
+	assert.True(t, product.Active)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetProduct_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewProductRepository(sqlxDB)
+
+	mock.ExpectQuery(`SELECT sku, name, unit_price, currency, active FROM products WHERE sku = \$1`).
+		WithArgs("SHOES-1").
+		WillReturnRows(sqlmock.NewRows([]string{"sku", "name", "unit_price", "currency", "active"}))
+
+	product, err := repo.Get(context.Background(), "SHOES-1")
+	assert.Nil(t, product)
+	assert.ErrorIs(t, err, carterror.ErrProductDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateProduct_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewProductRepository(sqlxDB)
+
+	product := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 4999, Currency: "USD"}
+
+	mock.ExpectExec(`UPDATE products SET name = \$2, unit_price = \$3, currency = \$4, active = \$5 WHERE sku = \$1`).
+		WithArgs("SHOES-1", "Shoes", int64(4999), "USD", false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = repo.Update(context.Background(), product)
+	assert.ErrorIs(t, err, carterror.ErrProductDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteProduct_InUse(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open mock database: %s", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := postgres.NewProductRepository(sqlxDB)
+
+	mock.ExpectExec(`DELETE FROM products WHERE sku = \$1`).
+		WithArgs("SHOES-1").
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	err = repo.Delete(context.Background(), "SHOES-1")
+	assert.ErrorIs(t, err, carterror.ErrProductInUse)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/tracing"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// txKey is the context key of the transaction of the current unit of work.
+type txKey struct{}
+
+// queryer is the part of sqlx.DB and sqlx.Tx used by the repositories.
+type queryer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// dbSystem labels the spans of the queries.
+const dbSystem = "postgresql"
+
+// conn returns the transaction of the unit of work running in ctx, or db outside of one.
+// Every query made through it is traced.
+func conn(ctx context.Context, db *sqlx.DB) queryer {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tracing.SQL(tx, dbSystem)
+	}
+	return tracing.SQL(db, dbSystem)
+}
+
+// inTx runs fn within the transaction of the unit of work running in ctx,
+// or within a new transaction that is committed if fn succeeds and rolled back otherwise.
+func inTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context, tx queryer) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx, conn(ctx, db))
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	ctx = context.WithValue(ctx, txKey{}, tx)
+	if err = fn(ctx, conn(ctx, db)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return carterror.ErrFailedPostgresOpperation
+	}
+	return nil
+}
+
+// Transactor runs units of work within a database transaction.
+type Transactor struct {
+	db *sqlx.DB
+}
+
+// NewTransactor creates a new instance of Transactor.
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx runs fn within a transaction that is committed if fn succeeds and rolled back otherwise.
+// Repository calls made with the context passed to fn take part in the transaction;
+// nested calls join the transaction that is already running.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, t.db, func(ctx context.Context, _ queryer) error {
+		return fn(ctx)
+	})
+}
@@ -0,0 +1,109 @@
+package postgres_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/db/postgres"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithinTx_Commit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	transactor := postgres.NewTransactor(sqlxDB)
+	carts := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, status, version FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "version"}).AddRow("cart-id", "active", 2))
+	mock.ExpectExec(`DELETE FROM carts WHERE id = \$1`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		cart, err := carts.Lock(ctx, "cart-id")
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, int64(2), cart.Version)
+		return carts.Delete(ctx, "cart-id")
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithinTx_RollbackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	transactor := postgres.NewTransactor(sqlxDB)
+	carts := postgres.NewCartRepository(sqlxDB)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, status, version FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "version"}))
+	mock.ExpectRollback()
+
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		_, err := carts.Lock(ctx, "cart-id")
+		return err
+	})
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWithinTx_RepositoryJoinsRunningTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' occurred when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	transactor := postgres.NewTransactor(sqlxDB)
+	items := postgres.NewCartItemRepository(sqlxDB)
+
+	// The item repository would start its own transaction, but joins the unit of work instead.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM carts WHERE id = \$1 FOR UPDATE`).
+		WithArgs("cart-id").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cart-id"))
+	mock.ExpectExec(`DELETE FROM cart_items WHERE id = \$1 AND cart_id = \$2`).
+		WithArgs("item-id", "cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE carts SET updated_at = now\(\)`).
+		WithArgs("cart-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		return items.Delete(ctx, "cart-id", "item-id")
+	})
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
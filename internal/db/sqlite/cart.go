@@ -0,0 +1,302 @@
+package sqlite
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CartRepository provides methods to interact with the carts table in the database.
+type CartRepository struct {
+	db *sqlx.DB
+}
+
+// NewCartRepository creates a new instance of CartRepository.
+func NewCartRepository(db *sqlx.DB) *CartRepository {
+	return &CartRepository{db: db}
+}
+
+// Create inserts a new cart owned by owner that expires after ttl into the database and returns the created cart.
+// The cart is initialized with an empty list of items.
+func (r *CartRepository) Create(ctx context.Context, owner model.Owner, ttl time.Duration) (*model.Cart, error) {
+	cart := model.Cart{CreatedAt: now()}
+	cart.UpdatedAt = cart.CreatedAt
+	cart.ExpiresAt = cart.CreatedAt.Add(ttl)
+	query := `INSERT INTO carts (owner_kind, owner_id, created_at, updated_at, expires_at, ttl) VALUES (?1, ?2, ?3, ?3, ?4, ?5)
+		RETURNING id, status, version`
+	err := conn(ctx, r.db).QueryRowxContext(ctx, query, owner.Kind, owner.ID, cart.CreatedAt, cart.ExpiresAt, int64(ttl)).Scan(&cart.ID, &cart.Status, &cart.Version)
+	if err != nil {
+		return nil, carterror.ErrFailedSQLiteOperation
+	}
+	cart.Items = []model.CartItem{}
+	cart.Transitions = []model.StatusTransition{}
+	return &cart, nil
+}
+
+// GetOwner retrieves the owner of a cart by its ID.
+func (r *CartRepository) GetOwner(ctx context.Context, id string) (model.Owner, error) {
+	var owner model.Owner
+	query := `SELECT COALESCE(owner_kind, '') AS owner_kind, COALESCE(owner_id, '') AS owner_id FROM carts WHERE id = ?1`
+	err := conn(ctx, r.db).GetContext(ctx, &owner, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Owner{}, carterror.ErrCartDoesNotExist
+	}
+	if err != nil {
+		return model.Owner{}, carterror.ErrFailedSQLiteOperation
+	}
+	return owner, nil
+}
+
+// Lock returns the cart with its status and version but without its items.
+// SQLite has no row locks; the transaction of the unit of work running in ctx
+// holds the write lock of the whole database until it ends.
+func (r *CartRepository) Lock(ctx context.Context, id string) (*model.Cart, error) {
+	var cart model.Cart
+	query := `SELECT id, status, version FROM carts WHERE id = ?1`
+	err := conn(ctx, r.db).GetContext(ctx, &cart, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, carterror.ErrCartDoesNotExist
+	}
+	if err != nil {
+		return nil, carterror.ErrFailedSQLiteOperation
+	}
+	return &cart, nil
+}
+
+// Get retrieves a cart by its ID, including all associated cart items.
+func (r *CartRepository) Get(ctx context.Context, id string) (*model.Cart, error) {
+	var cart model.Cart
+	query := `SELECT id, status, version, created_at, updated_at, expires_at, checked_out_at FROM carts WHERE id = ?1`
+	err := conn(ctx, r.db).GetContext(ctx, &cart, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, carterror.ErrCartDoesNotExist
+	}
+	if err != nil {
+		return nil, carterror.ErrFailedToRetrieveCart
+	}
+
+	transitionsQuery := `SELECT from_status, to_status, changed_at FROM cart_status_transitions WHERE cart_id = ?1 ORDER BY changed_at, id`
+	cart.Transitions = []model.StatusTransition{}
+	err = conn(ctx, r.db).SelectContext(ctx, &cart.Transitions, transitionsQuery, id)
+	if err != nil {
+		return nil, carterror.ErrFailedToRetrieveCart
+	}
+
+	itemsQuery := `SELECT id, cart_id, sku, quantity, unit_price, currency FROM cart_items WHERE cart_id = ?1`
+	err = conn(ctx, r.db).SelectContext(ctx, &cart.Items, itemsQuery, id)
+	if err != nil {
+		return nil, carterror.ErrFailedToRetrieveCartItems
+	}
+	return &cart, nil
+}
+
+// Delete removes a cart by its ID.
+// The cart items are removed by the ON DELETE CASCADE constraint on cart_items.
+func (r *CartRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM carts WHERE id = ?1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	if affected == 0 {
+		return carterror.ErrCartDoesNotExist
+	}
+	return nil
+}
+
+// Clear removes all items from the cart with the given ID, keeping the cart itself.
+func (r *CartRepository) Clear(ctx context.Context, id string) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, id); err != nil {
+			return err
+		}
+
+		query := `DELETE FROM cart_items WHERE cart_id = ?1`
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		return touchCart(ctx, tx, id)
+	})
+}
+
+// SetStatus changes the status of the cart from the given status to another one
+// and records the transition. It fails if the cart is no longer in the from status.
+func (r *CartRepository) SetStatus(ctx context.Context, id string, from, to model.CartStatus) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		return setCartStatus(ctx, tx, id, from, to)
+	})
+}
+
+// checkCartExists verifies that the cart exists.
+func checkCartExists(ctx context.Context, q sqlx.QueryerContext, cartID string) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM carts WHERE id = ?1)`
+	if err := q.QueryRowxContext(ctx, query, cartID).Scan(&exists); err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	if !exists {
+		return carterror.ErrCartDoesNotExist
+	}
+	return nil
+}
+
+// setCartStatus moves the cart from one status to another and records the transition.
+// Moving the cart to the checked out status also records the checkout time.
+// It must run within a transaction, so that the status and the transition are stored together.
+func setCartStatus(ctx context.Context, q sqlx.ExtContext, id string, from, to model.CartStatus) error {
+	changedAt := now()
+	query := `UPDATE carts SET status = ?3, version = version + 1, updated_at = ?4,
+			checked_out_at = CASE WHEN ?3 = 'checked_out' THEN ?4 ELSE checked_out_at END
+		WHERE id = ?1 AND status = ?2`
+	res, err := q.ExecContext(ctx, query, id, from, to, changedAt)
+	if err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	if affected > 0 {
+		query = `INSERT INTO cart_status_transitions (cart_id, from_status, to_status, changed_at) VALUES (?1, ?2, ?3, ?4)`
+		if _, err := q.ExecContext(ctx, query, id, from, to, changedAt); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		return nil
+	}
+
+	var exists bool
+	query = `SELECT EXISTS(SELECT 1 FROM carts WHERE id = ?1)`
+	if err := q.QueryRowxContext(ctx, query, id).Scan(&exists); err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	if !exists {
+		return carterror.ErrCartDoesNotExist
+	}
+	return carterror.ErrInvalidStatusTransition
+}
+
+// ExpireStale moves every cart in one of the given statuses whose expiry time has passed
+// to the expired status, records the transitions and returns the number of expired carts.
+func (r *CartRepository) ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error) {
+	statuses, err := json.Marshal(from)
+	if err != nil {
+		return 0, err
+	}
+	var affected int64
+	err = inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		// The transitions are recorded first, while the carts still have the status they are expired from.
+		query := `INSERT INTO cart_status_transitions (cart_id, from_status, to_status, changed_at)
+			SELECT id, status, 'expired', ?1 FROM carts
+			WHERE status IN (SELECT value FROM json_each(?2)) AND expires_at <= ?1`
+		if _, err := tx.ExecContext(ctx, query, now.UTC(), string(statuses)); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+
+		query = `UPDATE carts SET status = 'expired', version = version + 1, updated_at = ?1
+			WHERE status IN (SELECT value FROM json_each(?2)) AND expires_at <= ?1`
+		res, err := tx.ExecContext(ctx, query, now.UTC(), string(statuses))
+		if err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// PurgeExpired deletes the carts that expired before the given time and returns their number.
+// Their items, coupons and transitions are removed by the ON DELETE CASCADE constraints.
+func (r *CartRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM carts WHERE status = 'expired' AND updated_at < ?1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, carterror.ErrFailedSQLiteOperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, carterror.ErrFailedSQLiteOperation
+	}
+	return affected, nil
+}
+
+// touchCart marks the cart as changed by incrementing its version and extends its expiry by the lifetime it was created with.
+// SQLite has no interval arithmetic on the stored times, so the new expiry is computed here.
+func touchCart(ctx context.Context, q sqlx.ExtContext, id string) error {
+	var ttl time.Duration
+	query := `SELECT ttl FROM carts WHERE id = ?1`
+	if err := q.QueryRowxContext(ctx, query, id).Scan(&ttl); err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+
+	touchedAt := now()
+	query = `UPDATE carts SET updated_at = ?2, expires_at = ?3, version = version + 1 WHERE id = ?1`
+	if _, err := q.ExecContext(ctx, query, id, touchedAt, touchedAt.Add(ttl)); err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	return nil
+}
+
+// Merge moves the items and coupons of the source cart into the target cart
+// and deletes the source cart, all within a single transaction.
+// Quantities of SKUs present in both carts are summed and capped at maxQuantity;
+// the target cart keeps its own unit price for such lines.
+// It returns an error if either cart does not exist
+// or if the carts hold items in different currencies.
+func (r *CartRepository) Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, targetID); err != nil {
+			return err
+		}
+		if err := checkCartExists(ctx, tx, sourceID); err != nil {
+			return err
+		}
+
+		var mixed bool
+		query := `SELECT EXISTS(
+				SELECT 1 FROM cart_items s JOIN cart_items t ON t.cart_id = ?1
+				WHERE s.cart_id = ?2 AND s.currency <> t.currency
+			)`
+		if err := tx.QueryRowxContext(ctx, query, targetID, sourceID).Scan(&mixed); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		if mixed {
+			return carterror.ErrCurrencyMismatch
+		}
+
+		query = `INSERT INTO cart_items (cart_id, sku, quantity, unit_price, currency)
+			SELECT ?1, sku, MIN(quantity, ?3), unit_price, currency FROM cart_items WHERE cart_id = ?2
+			ON CONFLICT (cart_id, sku) DO UPDATE SET quantity = MIN(cart_items.quantity + excluded.quantity, ?3)`
+		if _, err := tx.ExecContext(ctx, query, targetID, sourceID, maxQuantity); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+
+		query = `INSERT INTO cart_coupons (cart_id, code)
+			SELECT ?1, code FROM cart_coupons WHERE cart_id = ?2
+			ON CONFLICT DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+
+		query = `DELETE FROM carts WHERE id = ?1`
+		if _, err := tx.ExecContext(ctx, query, sourceID); err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+
+		return touchCart(ctx, tx, targetID)
+	})
+}
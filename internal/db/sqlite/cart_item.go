@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// CartItemRepository provides methods to interact with the cart_items table in the database.
+type CartItemRepository struct {
+	db *sqlx.DB
+}
+
+// NewCartItemRepository creates a new instance of CartItemRepository.
+func NewCartItemRepository(db *sqlx.DB) *CartItemRepository {
+	return &CartItemRepository{db: db}
+}
+
+// Create inserts a new cart item into the database.
+// If the cart already has a line for the same SKU, the quantity of that line
+// is incremented instead, its unit price is refreshed and merged is reported as true;
+// increments beyond maxQuantity are rejected.
+// Items priced in a currency different from the rest of the cart are rejected.
+// It returns an error if the operation fails.
+func (r *CartItemRepository) Create(ctx context.Context, item *model.CartItem, maxQuantity int) (merged bool, err error) {
+	err = inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, item.CartID); err != nil {
+			return fmt.Errorf("Create: %w", err)
+		}
+		var mixed bool
+		query := `SELECT EXISTS(SELECT 1 FROM cart_items WHERE cart_id = ?1 AND currency <> ?2)`
+		if err := tx.QueryRowxContext(ctx, query, item.CartID, item.Currency).Scan(&mixed); err != nil {
+			return err
+		}
+		if mixed {
+			return carterror.ErrCurrencyMismatch
+		}
+
+		query = `SELECT EXISTS(SELECT 1 FROM cart_items WHERE cart_id = ?1 AND sku = ?2)`
+		if err := tx.QueryRowxContext(ctx, query, item.CartID, item.SKU).Scan(&merged); err != nil {
+			return err
+		}
+
+		query = `INSERT INTO cart_items (cart_id, sku, quantity, unit_price, currency) VALUES (?1, ?2, ?3, ?4, ?5)
+			ON CONFLICT (cart_id, sku) DO UPDATE SET quantity = cart_items.quantity + excluded.quantity,
+				unit_price = excluded.unit_price, currency = excluded.currency
+			WHERE cart_items.quantity + excluded.quantity <= ?6
+			RETURNING id, quantity`
+		err := tx.QueryRowxContext(ctx, query, item.CartID, item.SKU, item.Quantity, item.UnitPrice, item.Currency, maxQuantity).Scan(&item.ID, &item.Quantity)
+		if errors.Is(err, sql.ErrNoRows) {
+			return carterror.ErrQuantityTooLarge
+		}
+		if isViolation(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY) {
+			return carterror.ErrProductDoesNotExist
+		}
+		if err != nil {
+			return err
+		}
+		return touchCart(ctx, tx, item.CartID)
+	})
+	if err != nil {
+		return false, err
+	}
+	return merged, nil
+}
+
+// Delete removes a cart item from the database by its ID and cart ID.
+// It returns an error if the cart does not exist or the operation fails.
+func (r *CartItemRepository) Delete(ctx context.Context, cartID, cartItemID string) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, cartID); err != nil {
+			return err
+		}
+		query := `DELETE FROM cart_items WHERE id = ?1 AND cart_id = ?2`
+		res, err := tx.ExecContext(ctx, query, cartItemID, cartID)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return carterror.ErrCartItemDoesNotExist
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
+
+// UpdateQuantity sets the quantity of a cart item identified by its ID and cart ID
+// and returns the updated item.
+// It returns an error if the cart or the item does not exist or the operation fails.
+func (r *CartItemRepository) UpdateQuantity(ctx context.Context, cartID, cartItemID string, quantity int) (*model.CartItem, error) {
+	var item model.CartItem
+	err := inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, cartID); err != nil {
+			return err
+		}
+
+		query := `UPDATE cart_items SET quantity = ?1 WHERE id = ?2 AND cart_id = ?3 RETURNING id, cart_id, sku, quantity, unit_price, currency`
+		err := tx.QueryRowxContext(ctx, query, quantity, cartItemID, cartID).StructScan(&item)
+		if errors.Is(err, sql.ErrNoRows) {
+			return carterror.ErrCartItemDoesNotExist
+		}
+		if err != nil {
+			return err
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
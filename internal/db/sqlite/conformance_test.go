@@ -0,0 +1,40 @@
+package sqlite_test
+
+import (
+	"cart-api/internal/db/sqlite"
+	"cart-api/internal/storagetest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+)
+
+// openTestDB opens a new database in a temporary directory and applies the SQLite migrations.
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "cart.db"))
+	if err != nil {
+		t.Fatalf("failed to open the test database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("failed to set dialect: %s", err)
+	}
+	if err := goose.Up(db.DB, "../../../migrations/sqlite"); err != nil {
+		t.Fatalf("failed to apply migrations: %s", err)
+	}
+	return db
+}
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		db := openTestDB(t)
+		return storagetest.Backend{
+			Carts:    sqlite.NewCartRepository(db),
+			Items:    sqlite.NewCartItemRepository(db),
+			Products: sqlite.NewProductRepository(db),
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"cart-api/internal/config"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+// migrationsDir is the directory of the SQLite migrations, relative to the working directory.
+const migrationsDir = "migrations/sqlite"
+
+// Open opens the SQLite database file at path, creating it if it does not exist.
+// Foreign keys are enforced and every transaction takes the write lock when it begins,
+// so a unit of work cannot be interleaved with other writers.
+// The pool holds a single connection, which serializes the queries of the application.
+func Open(path string) (*sqlx.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := sqlx.Connect("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Connect opens the database file configured by cfg.
+// If the database cannot be opened it returns an error.
+// It does not apply migrations; see NewMigrator.
+func Connect(cfg config.Config) (*sqlx.DB, error) {
+	return Open(cfg.SQLitePath)
+}
+
+// NewMigrator returns a goose provider that applies the migrations of the migrations directory to db.
+// The provider must not be closed, since that closes db.
+func NewMigrator(db *sqlx.DB) (*goose.Provider, error) {
+	p, err := goose.NewProvider(goose.DialectSQLite3, db.DB, os.DirFS(migrationsDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// couponColumns lists the coupon columns with the optional ones defaulted,
+// so that they can be scanned into model.Coupon.
+const couponColumns = `code, kind, COALESCE(percent_off, 0) AS percent_off, COALESCE(amount_off, 0) AS amount_off,
+	COALESCE(currency, '') AS currency, COALESCE(sku, '') AS sku, COALESCE(buy_quantity, 0) AS buy_quantity,
+	COALESCE(get_quantity, 0) AS get_quantity, min_subtotal, starts_at, ends_at, usage_limit, times_used`
+
+// CouponRepository provides methods to interact with the coupons and cart_coupons tables in the database.
+type CouponRepository struct {
+	db *sqlx.DB
+}
+
+// NewCouponRepository creates a new instance of CouponRepository.
+func NewCouponRepository(db *sqlx.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+// Get retrieves a coupon by its code.
+func (r *CouponRepository) Get(ctx context.Context, code string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = ?1`
+	err := conn(ctx, r.db).GetContext(ctx, &coupon, query, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, carterror.ErrCouponDoesNotExist
+	}
+	if err != nil {
+		return nil, carterror.ErrFailedSQLiteOperation
+	}
+	return &coupon, nil
+}
+
+// ListForCart retrieves the coupons attached to the cart with the given ID, ordered by code.
+func (r *CouponRepository) ListForCart(ctx context.Context, cartID string) ([]model.Coupon, error) {
+	coupons := []model.Coupon{}
+	query := `SELECT ` + couponColumns + ` FROM coupons
+		WHERE code IN (SELECT code FROM cart_coupons WHERE cart_id = ?1) ORDER BY code`
+	err := conn(ctx, r.db).SelectContext(ctx, &coupons, query, cartID)
+	if err != nil {
+		return nil, carterror.ErrFailedSQLiteOperation
+	}
+	return coupons, nil
+}
+
+// Attach attaches a coupon to the cart. Attaching a coupon twice has no effect.
+func (r *CouponRepository) Attach(ctx context.Context, cartID, code string) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, cartID); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO cart_coupons (cart_id, code) VALUES (?1, ?2) ON CONFLICT DO NOTHING`
+		_, err := tx.ExecContext(ctx, query, cartID, code)
+		if isViolation(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY) {
+			return carterror.ErrCouponDoesNotExist
+		}
+		if err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
+
+// Detach removes a coupon from the cart.
+// It returns an error if the coupon is not attached to the cart.
+func (r *CouponRepository) Detach(ctx context.Context, cartID, code string) error {
+	return inTx(ctx, r.db, func(ctx context.Context, tx queryer) error {
+		if err := checkCartExists(ctx, tx, cartID); err != nil {
+			return err
+		}
+
+		query := `DELETE FROM cart_coupons WHERE cart_id = ?1 AND code = ?2`
+		res, err := tx.ExecContext(ctx, query, cartID, code)
+		if err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return carterror.ErrFailedSQLiteOperation
+		}
+		if affected == 0 {
+			return carterror.ErrCouponNotApplied
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
@@ -0,0 +1,105 @@
+package sqlite
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyRepository provides methods to interact with the idempotency_keys table in the database.
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository.
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims the key within scope for a request with the given hash until expiresAt.
+// The request holds the key until lockedUntil; if it has not completed by then, a retry of the same request takes it over.
+// A key whose previous reservation has expired is claimed again.
+// If the key is taken, it returns the stored record and false.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*model.IdempotencyRecord, bool, error) {
+	query := `INSERT INTO idempotency_keys (scope, key, request_hash, created_at, locked_until, expires_at) VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			request_hash = excluded.request_hash, status_code = NULL, response_headers = NULL,
+			response_body = NULL, created_at = excluded.created_at, locked_until = excluded.locked_until,
+			expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= excluded.created_at
+			OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= excluded.created_at
+				AND idempotency_keys.request_hash = excluded.request_hash)
+		RETURNING key`
+	var reserved string
+	err := conn(ctx, r.db).QueryRowxContext(ctx, query, scope, key, requestHash, now(), lockedUntil.UTC(), expiresAt.UTC()).Scan(&reserved)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, carterror.ErrFailedSQLiteOperation
+	}
+
+	var row struct {
+		RequestHash string        `db:"request_hash"`
+		StatusCode  sql.NullInt32 `db:"status_code"`
+		Header      []byte        `db:"response_headers"`
+		Body        []byte        `db:"response_body"`
+	}
+	query = `SELECT request_hash, status_code, response_headers, response_body FROM idempotency_keys WHERE scope = ?1 AND key = ?2`
+	if err := conn(ctx, r.db).GetContext(ctx, &row, query, scope, key); err != nil {
+		return nil, false, carterror.ErrFailedSQLiteOperation
+	}
+
+	record := &model.IdempotencyRecord{RequestHash: row.RequestHash}
+	if row.StatusCode.Valid {
+		response := &model.RecordedResponse{StatusCode: int(row.StatusCode.Int32), Body: row.Body}
+		if err := json.Unmarshal(row.Header, &response.Header); err != nil {
+			return nil, false, err
+		}
+		record.Response = response
+	}
+	return record, false, nil
+}
+
+// Complete stores the response of the request that reserved the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE idempotency_keys SET status_code = ?3, response_headers = ?4, response_body = ?5
+		WHERE scope = ?1 AND key = ?2`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, key, response.StatusCode, string(header), response.Body); err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	return nil
+}
+
+// Release removes the reservation of a key whose request has not completed.
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = ?1 AND key = ?2 AND status_code IS NULL`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, scope, key); err != nil {
+		return carterror.ErrFailedSQLiteOperation
+	}
+	return nil
+}
+
+// PurgeExpired deletes the keys that expired before the given time and returns their number.
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at < ?1`
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, carterror.ErrFailedSQLiteOperation
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, carterror.ErrFailedSQLiteOperation
+	}
+	return affected, nil
+}
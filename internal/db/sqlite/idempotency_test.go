@@ -0,0 +1,86 @@
+package sqlite_test
+
+import (
+	"cart-api/internal/db/sqlite"
+	"cart-api/internal/model"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyRepository(t *testing.T) {
+	repo := sqlite.NewIdempotencyRepository(openTestDB(t))
+	ctx := context.Background()
+
+	record, reserved, err := repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Nil(t, record)
+
+	record, reserved, err = repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "hash", record.RequestHash)
+	assert.Nil(t, record.Response)
+
+	response := model.RecordedResponse{StatusCode: http.StatusCreated, Header: map[string]string{"Content-Type": "application/json"}, Body: []byte(`{}`)}
+	require.NoError(t, repo.Complete(ctx, "user:1", "key", response))
+
+	record, reserved, err = repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, &response, record.Response)
+
+	_, reserved, err = repo.Reserve(ctx, "user:2", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reserved, "keys are scoped to the caller")
+
+	purged, err := repo.PurgeExpired(ctx, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestIdempotencyRepository_ReclaimsExpiredKey(t *testing.T) {
+	repo := sqlite.NewIdempotencyRepository(openTestDB(t))
+	ctx := context.Background()
+
+	_, reserved, err := repo.Reserve(ctx, "", "key", "old", time.Now().Add(time.Minute), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	_, reserved, err = repo.Reserve(ctx, "", "key", "new", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	require.NoError(t, repo.Release(ctx, "", "key"))
+	_, reserved, err = repo.Reserve(ctx, "", "key", "other", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
+
+func TestIdempotencyRepository_TakesOverLapsedLease(t *testing.T) {
+	repo := sqlite.NewIdempotencyRepository(openTestDB(t))
+	ctx := context.Background()
+
+	_, reserved, err := repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(-time.Second), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	record, reserved, err := repo.Reserve(ctx, "user:1", "key", "other", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, reserved, "only a retry of the same request takes over the lease")
+	assert.Equal(t, "hash", record.RequestHash)
+
+	_, reserved, err = repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	record, reserved, err = repo.Reserve(ctx, "user:1", "key", "hash", time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, reserved, "the new lease has not lapsed")
+	assert.Nil(t, record.Response)
+}
@@ -0,0 +1,64 @@
+package sqlite_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/db/sqlite"
+	"cart-api/internal/model"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrder(t *testing.T) {
+	db := openTestDB(t)
+	carts := sqlite.NewCartRepository(db)
+	coupons := sqlite.NewCouponRepository(db)
+	orders := sqlite.NewOrderRepository(db)
+
+	_, err := db.Exec(`INSERT INTO coupons (code, kind, percent_off, usage_limit) VALUES ('SAVE10', 'percentage', 10, 1)`)
+	require.NoError(t, err)
+
+	checkout := func() (*model.Order, error) {
+		cart, err := carts.Create(context.Background(), model.Owner{Kind: model.OwnerUser, ID: "user-1"}, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, coupons.Attach(context.Background(), cart.ID, "SAVE10"))
+
+		order := &model.Order{
+			CartID:        cart.ID,
+			Currency:      "USD",
+			Lines:         []model.OrderLine{{SKU: "apple", Quantity: 2, UnitPrice: 100, LineTotal: 200}},
+			Subtotal:      200,
+			Discounts:     []model.AppliedDiscount{{Code: "SAVE10", Kind: model.CouponPercentage, Amount: 20}},
+			DiscountTotal: 20,
+			Total:         180,
+		}
+		if err := orders.Create(context.Background(), order); err != nil {
+			return nil, err
+		}
+		return order, carts.SetStatus(context.Background(), cart.ID, model.CartActive, model.CartCheckedOut)
+	}
+
+	order, err := checkout()
+	require.NoError(t, err)
+	assert.NotEmpty(t, order.ID)
+	assert.False(t, order.CreatedAt.IsZero())
+
+	cart, err := carts.Get(context.Background(), order.CartID)
+	require.NoError(t, err)
+	assert.Equal(t, model.CartCheckedOut, cart.Status)
+	require.NotNil(t, cart.CheckedOutAt)
+	assert.Equal(t, cart.Transitions[0].At, *cart.CheckedOutAt)
+
+	coupon, err := coupons.Get(context.Background(), "SAVE10")
+	require.NoError(t, err)
+	assert.Equal(t, 1, coupon.TimesUsed)
+
+	_, err = checkout()
+	assert.ErrorIs(t, err, carterror.ErrCouponExhausted)
+
+	_, err = db.Exec(`UPDATE orders SET total = 0 WHERE id = ?`, order.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package sqlite_test
+
+import (
+	"cart-api/internal/db/sqlite"
+	"cart-api/internal/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithinTx_RollsBackOnError(t *testing.T) {
+	db := openTestDB(t)
+	carts := sqlite.NewCartRepository(db)
+	transactor := sqlite.NewTransactor(db)
+
+	cart, err := carts.Create(context.Background(), model.Owner{Kind: model.OwnerUser, ID: "user-1"}, time.Hour)
+	require.NoError(t, err)
+
+	failure := errors.New("failure")
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := carts.Delete(ctx, cart.ID); err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+
+	_, err = carts.Get(context.Background(), cart.ID)
+	assert.NoError(t, err)
+}
+
+func TestWithinTx_Commits(t *testing.T) {
+	db := openTestDB(t)
+	carts := sqlite.NewCartRepository(db)
+	transactor := sqlite.NewTransactor(db)
+
+	cart, err := carts.Create(context.Background(), model.Owner{Kind: model.OwnerUser, ID: "user-1"}, time.Hour)
+	require.NoError(t, err)
+
+	err = transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := carts.Lock(ctx, cart.ID); err != nil {
+			return err
+		}
+		return carts.SetStatus(ctx, cart.ID, model.CartActive, model.CartLocked)
+	})
+	require.NoError(t, err)
+
+	got, err := carts.Get(context.Background(), cart.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.CartLocked, got.Status)
+	assert.Equal(t, cart.Version+1, got.Version)
+	require.Len(t, got.Transitions, 1)
+	assert.Equal(t, model.CartActive, got.Transitions[0].From)
+	assert.Nil(t, got.CheckedOutAt)
+}
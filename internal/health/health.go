@@ -0,0 +1,104 @@
+// Package health serves the liveness and readiness probes of the application.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// checkTimeout bounds how long a single readiness check may take.
+const checkTimeout = 2 * time.Second
+
+// errShuttingDown fails the readiness of an application that is shutting down.
+var errShuttingDown = errors.New("shutting down")
+
+// Check reports an error while a dependency of the application is not usable.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of the application and tracks whether it is shutting down.
+type Checker struct {
+	checks   []namedCheck
+	draining atomic.Bool
+	logger   *slog.Logger
+}
+
+// New creates a new instance of Checker that logs failed checks to logger.
+func New(logger *slog.Logger) *Checker {
+	return &Checker{logger: logger}
+}
+
+// Add registers check under name. Checks must be added before the probes are served.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain makes the readiness probe fail from now on, so that load balancers stop sending requests
+// to the application before it shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// response is the body of the probes: the overall status and the outcome of every check.
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Live returns the liveness probe, which succeeds as long as the process serves requests.
+func (c *Checker) Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, http.StatusOK, response{Status: "ok"})
+	})
+}
+
+// Ready returns the readiness probe, which succeeds when every check passes and the application is not shutting down.
+// Failed checks are reported by name without their error, which is logged instead.
+func (c *Checker) Ready() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		status := http.StatusOK
+		resp := response{Status: "ok", Checks: map[string]string{}}
+		report := func(name string, err error) {
+			if err == nil {
+				resp.Checks[name] = "ok"
+				return
+			}
+			resp.Checks[name] = "failing"
+			resp.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+
+		var shutdown error
+		if c.draining.Load() {
+			shutdown = errShuttingDown
+		}
+		report("shutdown", shutdown)
+		for _, nc := range c.checks {
+			err := nc.check(ctx)
+			if err != nil {
+				c.logger.WarnContext(ctx, "readiness check failed", "check", nc.name, "error", err)
+			}
+			report(nc.name, err)
+		}
+		write(w, status, resp)
+	})
+}
+
+func write(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,90 @@
+package health_test
+
+import (
+	"cart-api/internal/db/sqlite"
+	"cart-api/internal/health"
+	"cart-api/internal/logging"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type probeResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func probe(t *testing.T, h http.Handler) (int, probeResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var resp probeResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return w.Code, resp
+}
+
+func TestLive(t *testing.T) {
+	c := health.New(logging.Discard())
+	c.Add("database", func(context.Context) error { return errors.New("connection refused") })
+	c.Drain()
+
+	code, resp := probe(t, c.Live())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+}
+
+func TestReady(t *testing.T) {
+	var dbErr error
+	c := health.New(logging.Discard())
+	c.Add("database", func(context.Context) error { return dbErr })
+	c.Add("migrations", func(context.Context) error { return nil })
+
+	code, resp := probe(t, c.Ready())
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, probeResponse{Status: "ok", Checks: map[string]string{"database": "ok", "migrations": "ok", "shutdown": "ok"}}, resp)
+
+	dbErr = errors.New("connection refused")
+	code, resp = probe(t, c.Ready())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, probeResponse{Status: "unavailable", Checks: map[string]string{"database": "failing", "migrations": "ok", "shutdown": "ok"}}, resp)
+}
+
+func TestReady_Drain(t *testing.T) {
+	c := health.New(logging.Discard())
+
+	code, _ := probe(t, c.Ready())
+	assert.Equal(t, http.StatusOK, code)
+
+	c.Drain()
+	code, resp := probe(t, c.Ready())
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "failing", resp.Checks["shutdown"])
+}
+
+func TestMigrations(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "cart.db"))
+	require.NoError(t, err)
+	defer db.Close()
+	p, err := goose.NewProvider(goose.DialectSQLite3, db.DB, os.DirFS("../../migrations/sqlite"))
+	require.NoError(t, err)
+	check := health.Migrations(p)
+	ctx := context.Background()
+
+	sources := p.ListSources()
+	latest := sources[len(sources)-1].Version
+	assert.EqualError(t, check(ctx), fmt.Sprintf("database is at version 0, expected %d", latest))
+
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, check(ctx))
+}
@@ -0,0 +1,22 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Migrations returns a check that fails while the database of p is not at the version of the latest migration of p.
+func Migrations(p *goose.Provider) Check {
+	return func(ctx context.Context) error {
+		current, target, err := p.GetVersions(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get migration versions: %w", err)
+		}
+		if current != target {
+			return fmt.Errorf("database is at version %d, expected %d", current, target)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"cart-api/internal/transport/http/recorder"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+type requestKey struct{}
+
+// request collects what is logged about a request once it is served.
+type request struct {
+	err error
+}
+
+// RecordError attaches err to the request ctx belongs to, so that it is logged with the request.
+// It does nothing outside of a request served by Route.
+func RecordError(ctx context.Context, err error) {
+	if req, ok := ctx.Value(requestKey{}).(*request); ok {
+		req.err = err
+	}
+}
+
+// Route returns a handler that serves every request to next with the method and the route pattern
+// attached to its context and logs the request with its status and duration once it is served.
+// Requests that fail with a server error are logged at the error level along with the recorded error.
+func Route(logger *slog.Logger, pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		req := &request{}
+		ctx := With(r.Context(), slog.String("method", r.Method), slog.String("route", pattern))
+		ctx = context.WithValue(ctx, requestKey{}, req)
+
+		rec := recorder.New(w)
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		attrs := []slog.Attr{slog.Int("status", rec.Status()), slog.Duration("duration", time.Since(start))}
+		if rec.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		if req.err != nil {
+			attrs = append(attrs, slog.String("error", req.err.Error()))
+		}
+		logger.LogAttrs(ctx, level, "request", attrs...)
+	})
+}
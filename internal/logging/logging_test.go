@@ -0,0 +1,110 @@
+package logging_test
+
+import (
+	"bytes"
+	"cart-api/internal/logging"
+	"cart-api/internal/transport/http/requestid"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// records decodes the JSON lines written by a logger.
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		out = append(out, record)
+	}
+	return out
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatText, "WARN")
+	require.NoError(t, err)
+	logger.Info("dropped")
+	logger.Warn("kept", "cart_id", "1")
+	assert.NotContains(t, buf.String(), "dropped")
+	assert.Contains(t, buf.String(), "level=WARN msg=kept cart_id=1")
+
+	_, err = logging.New(&buf, "xml", "info")
+	assert.Error(t, err)
+	_, err = logging.New(&buf, logging.FormatJSON, "verbose")
+	assert.Error(t, err)
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "debug")
+	require.NoError(t, err)
+
+	ctx := logging.With(context.Background(), slog.String("request_id", "abc"))
+	ctx = logging.With(ctx, slog.String("route", "GET /carts/{id}"))
+	logger.DebugContext(ctx, "view cart", "cart_id", "1")
+	logger.Debug("without context")
+
+	got := records(t, &buf)
+	require.Len(t, got, 2)
+	assert.Equal(t, "abc", got[0]["request_id"])
+	assert.Equal(t, "GET /carts/{id}", got[0]["route"])
+	assert.Equal(t, "1", got[0]["cart_id"])
+	assert.NotContains(t, got[1], "request_id")
+}
+
+func TestRoute(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "debug")
+	require.NoError(t, err)
+
+	router := http.NewServeMux()
+	router.Handle("GET /carts/{id}", logging.Route(logger, "GET /carts/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.DebugContext(r.Context(), "view cart", "cart_id", r.PathValue("id"))
+		if r.PathValue("id") == "broken" {
+			logging.RecordError(r.Context(), errors.New("connection reset"))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("{}"))
+	})))
+	h := requestid.Middleware(router)
+
+	r := httptest.NewRequest(http.MethodGet, "/carts/1", nil)
+	r.Header.Set(requestid.Header, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/carts/broken", nil))
+
+	got := records(t, &buf)
+	require.Len(t, got, 4)
+	for _, record := range got {
+		assert.Equal(t, "GET", record["method"])
+		assert.Equal(t, "GET /carts/{id}", record["route"])
+		assert.NotEmpty(t, record["request_id"])
+	}
+	assert.Equal(t, "view cart", got[0]["msg"])
+	assert.Equal(t, "req-1", got[0]["request_id"])
+	assert.Equal(t, "request", got[1]["msg"])
+	assert.Equal(t, "req-1", got[1]["request_id"])
+	assert.Equal(t, "INFO", got[1]["level"])
+	assert.EqualValues(t, http.StatusOK, got[1]["status"])
+	assert.Contains(t, got[1], "duration")
+	assert.NotContains(t, got[1], "error")
+
+	assert.Equal(t, got[2]["request_id"], got[3]["request_id"])
+	assert.Equal(t, "ERROR", got[3]["level"])
+	assert.EqualValues(t, http.StatusInternalServerError, got[3]["status"])
+	assert.Equal(t, "connection reset", got[3]["error"])
+}
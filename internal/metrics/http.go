@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"cart-api/internal/transport/http/recorder"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Route returns a handler that counts the requests to next and measures their latency,
+// labeled with the pattern next is registered with.
+// The pattern is used instead of the request path so that IDs in the path do not multiply the series.
+func (m *Metrics) Route(pattern string, next http.Handler) http.Handler {
+	duration := m.requestDuration.WithLabelValues(pattern)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := recorder.New(w)
+		next.ServeHTTP(rec, r)
+		duration.Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(pattern, strconv.Itoa(rec.Status())).Inc()
+	})
+}
@@ -0,0 +1,116 @@
+// Package metrics collects the Prometheus metrics of the application
+// and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"cart-api/internal/cache"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all metrics of the application.
+const namespace = "cart_api"
+
+// Metrics holds the collectors of the application and the registry they are exposed from.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+
+	cartsCreated prometheus.Counter
+	itemsAdded   prometheus.Counter
+	itemsRemoved prometheus.Counter
+	checkouts    prometheus.Counter
+}
+
+// New creates a new instance of Metrics with the Go runtime and process collectors registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern and status code.",
+		}, []string{"route", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of storage calls by repository and method.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"repository", "method"}),
+		cartsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "carts_created_total",
+			Help:      "Carts created.",
+		}),
+		itemsAdded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cart_items_added_total",
+			Help:      "Items added to carts; adding a SKU that is already in the cart counts as well.",
+		}),
+		itemsRemoved: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cart_items_removed_total",
+			Help:      "Items removed from carts one by one.",
+		}),
+		checkouts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "checkouts_total",
+			Help:      "Carts checked out into orders.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration, m.queryDuration,
+		m.cartsCreated, m.itemsAdded, m.itemsRemoved, m.checkouts,
+	)
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDBStats exposes the connection pool statistics of db, labeled with name.
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCartCache exposes the hits and misses of the cart cache reported by stats.
+func (m *Metrics) RegisterCartCache(stats func() cache.Stats) {
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cart_cache_hits_total",
+			Help:      "Cart reads served from the cache.",
+		}, func() float64 { return float64(stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cart_cache_misses_total",
+			Help:      "Cart reads that missed the cache and went to the storage.",
+		}, func() float64 { return float64(stats().Misses) }),
+	)
+}
+
+// observe starts timing a storage call and returns the function that records its duration.
+func (m *Metrics) observe(repository, method string) func() {
+	start := time.Now()
+	return func() {
+		m.queryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,113 @@
+package metrics_test
+
+import (
+	"cart-api/internal/cache"
+	"cart-api/internal/db/memory"
+	"cart-api/internal/metrics"
+	"cart-api/internal/model"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scrape returns the metrics of m in the Prometheus text format.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestRoute(t *testing.T) {
+	m := metrics.New()
+	router := http.NewServeMux()
+	router.Handle("GET /carts/{id}", m.Route("GET /carts/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("{}"))
+	})))
+
+	for _, path := range []string{"/carts/a", "/carts/b", "/carts/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `cart_api_http_requests_total{code="200",route="GET /carts/{id}"} 2`)
+	assert.Contains(t, body, `cart_api_http_requests_total{code="404",route="GET /carts/{id}"} 1`)
+	assert.Contains(t, body, `cart_api_http_request_duration_seconds_count{route="GET /carts/{id}"} 3`)
+}
+
+func TestStorage(t *testing.T) {
+	m := metrics.New()
+	store := memory.NewStore()
+	carts := m.Carts(memory.NewCartRepository(store))
+	items := m.Items(memory.NewCartItemRepository(store))
+	products := m.Products(memory.NewProductRepository(store))
+	ctx := context.Background()
+
+	apple := model.Product{SKU: "apple", Name: "Apple", UnitPrice: 100, Currency: "USD", Active: true}
+	require.NoError(t, products.Create(ctx, &apple))
+	cart, err := carts.Create(ctx, model.Owner{Kind: model.OwnerUser, ID: "user-1"}, time.Hour)
+	require.NoError(t, err)
+	item := &model.CartItem{CartID: cart.ID, SKU: "apple", Quantity: 2, UnitPrice: 100, Currency: "USD"}
+	_, err = items.Create(ctx, item, 999)
+	require.NoError(t, err)
+	_, err = items.Create(ctx, &model.CartItem{CartID: cart.ID, SKU: "plum", Quantity: 1, UnitPrice: 100, Currency: "USD"}, 999)
+	require.Error(t, err)
+	require.NoError(t, items.Delete(ctx, cart.ID, item.ID))
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "cart_api_carts_created_total 1")
+	assert.Contains(t, body, "cart_api_cart_items_added_total 1")
+	assert.Contains(t, body, "cart_api_cart_items_removed_total 1")
+	assert.Contains(t, body, "cart_api_checkouts_total 0")
+	assert.Contains(t, body, `cart_api_db_query_duration_seconds_count{method="Create",repository="cart_items"} 2`)
+	assert.Contains(t, body, `cart_api_db_query_duration_seconds_count{method="Create",repository="carts"} 1`)
+}
+
+func TestStorage_CountsOnlyCommittedUnitsOfWork(t *testing.T) {
+	m := metrics.New()
+	store := memory.NewStore()
+	carts := m.Carts(memory.NewCartRepository(store))
+	tx := m.Transactor(memory.NewTransactor(store))
+	ctx := context.Background()
+	owner := model.Owner{Kind: model.OwnerUser, ID: "user-1"}
+
+	rollback := errors.New("rollback")
+	err := tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := carts.Create(ctx, owner, time.Hour)
+		require.NoError(t, err)
+		assert.Contains(t, scrape(t, m), "cart_api_carts_created_total 0")
+		return rollback
+	})
+	require.ErrorIs(t, err, rollback)
+	assert.Contains(t, scrape(t, m), "cart_api_carts_created_total 0")
+
+	err = tx.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := carts.Create(ctx, owner, time.Hour)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Contains(t, scrape(t, m), "cart_api_carts_created_total 1")
+}
+
+func TestRegisterCartCache(t *testing.T) {
+	m := metrics.New()
+	m.RegisterCartCache(func() cache.Stats { return cache.Stats{Hits: 3, Misses: 1} })
+
+	body := scrape(t, m)
+	assert.Contains(t, body, "cart_api_cart_cache_hits_total 3")
+	assert.Contains(t, body, "cart_api_cart_cache_misses_total 1")
+}
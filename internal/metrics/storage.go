@@ -0,0 +1,269 @@
+package metrics
+
+import (
+	"cart-api/internal/cache"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// eventsKey is the context key of the business events recorded by the unit of work running in the context.
+type eventsKey struct{}
+
+// events collects the business events recorded by a unit of work until it has committed.
+type events struct {
+	mu       sync.Mutex
+	counters []prometheus.Counter
+}
+
+// count counts a business event.
+// Within a unit of work the event is counted only once the unit of work has committed,
+// because until then it may still be rolled back.
+func (m *Metrics) count(ctx context.Context, c prometheus.Counter) {
+	if e, ok := ctx.Value(eventsKey{}).(*events); ok {
+		e.mu.Lock()
+		e.counters = append(e.counters, c)
+		e.mu.Unlock()
+		return
+	}
+	c.Inc()
+}
+
+// Carts wraps the cart storage of a backend, so that its calls are timed and created carts are counted.
+func (m *Metrics) Carts(carts cache.CartStore) cache.CartStore {
+	return &cartStorage{next: carts, m: m}
+}
+
+// Items wraps the item storage of a backend, so that its calls are timed and added and removed items are counted.
+func (m *Metrics) Items(items service.CartItemStorage) service.CartItemStorage {
+	return &itemStorage{next: items, m: m}
+}
+
+// Products wraps the product storage of a backend, so that its calls are timed.
+func (m *Metrics) Products(products service.ProductStorage) service.ProductStorage {
+	return &productStorage{next: products, m: m}
+}
+
+// Coupons wraps the coupon storage of a backend, so that its calls are timed.
+func (m *Metrics) Coupons(coupons service.CouponStorage) service.CouponStorage {
+	return &couponStorage{next: coupons, m: m}
+}
+
+// Orders wraps the order storage of a backend, so that its calls are timed and checkouts are counted.
+func (m *Metrics) Orders(orders service.OrderStorage) service.OrderStorage {
+	return &orderStorage{next: orders, m: m}
+}
+
+// Transactor wraps the transactor of a backend, so that the business events recorded by a unit of work
+// are counted only once it has committed.
+func (m *Metrics) Transactor(tx service.Transactor) service.Transactor {
+	return &transactor{next: tx}
+}
+
+// IdempotencyKeys wraps the idempotency key storage of a backend, so that its calls are timed.
+func (m *Metrics) IdempotencyKeys(keys service.IdempotencyStorage) service.IdempotencyStorage {
+	return &keyStorage{next: keys, m: m}
+}
+
+type cartStorage struct {
+	next cache.CartStore
+	m    *Metrics
+}
+
+func (s *cartStorage) Create(ctx context.Context, owner model.Owner, ttl time.Duration) (*model.Cart, error) {
+	defer s.m.observe("carts", "Create")()
+	cart, err := s.next.Create(ctx, owner, ttl)
+	if err == nil {
+		s.m.count(ctx, s.m.cartsCreated)
+	}
+	return cart, err
+}
+
+func (s *cartStorage) Get(ctx context.Context, id string) (*model.Cart, error) {
+	defer s.m.observe("carts", "Get")()
+	return s.next.Get(ctx, id)
+}
+
+func (s *cartStorage) GetOwner(ctx context.Context, id string) (model.Owner, error) {
+	defer s.m.observe("carts", "GetOwner")()
+	return s.next.GetOwner(ctx, id)
+}
+
+func (s *cartStorage) Lock(ctx context.Context, id string) (*model.Cart, error) {
+	defer s.m.observe("carts", "Lock")()
+	return s.next.Lock(ctx, id)
+}
+
+func (s *cartStorage) Delete(ctx context.Context, id string) error {
+	defer s.m.observe("carts", "Delete")()
+	return s.next.Delete(ctx, id)
+}
+
+func (s *cartStorage) Clear(ctx context.Context, id string) error {
+	defer s.m.observe("carts", "Clear")()
+	return s.next.Clear(ctx, id)
+}
+
+func (s *cartStorage) SetStatus(ctx context.Context, id string, from, to model.CartStatus) error {
+	defer s.m.observe("carts", "SetStatus")()
+	return s.next.SetStatus(ctx, id, from, to)
+}
+
+func (s *cartStorage) Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error {
+	defer s.m.observe("carts", "Merge")()
+	return s.next.Merge(ctx, targetID, sourceID, maxQuantity)
+}
+
+func (s *cartStorage) ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error) {
+	defer s.m.observe("carts", "ExpireStale")()
+	return s.next.ExpireStale(ctx, now, from)
+}
+
+func (s *cartStorage) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	defer s.m.observe("carts", "PurgeExpired")()
+	return s.next.PurgeExpired(ctx, before)
+}
+
+type itemStorage struct {
+	next service.CartItemStorage
+	m    *Metrics
+}
+
+func (s *itemStorage) Create(ctx context.Context, item *model.CartItem, maxQuantity int) (bool, error) {
+	defer s.m.observe("cart_items", "Create")()
+	merged, err := s.next.Create(ctx, item, maxQuantity)
+	if err == nil {
+		s.m.count(ctx, s.m.itemsAdded)
+	}
+	return merged, err
+}
+
+func (s *itemStorage) Delete(ctx context.Context, cartID, cartItemID string) error {
+	defer s.m.observe("cart_items", "Delete")()
+	err := s.next.Delete(ctx, cartID, cartItemID)
+	if err == nil {
+		s.m.count(ctx, s.m.itemsRemoved)
+	}
+	return err
+}
+
+func (s *itemStorage) UpdateQuantity(ctx context.Context, cartID, cartItemID string, quantity int) (*model.CartItem, error) {
+	defer s.m.observe("cart_items", "UpdateQuantity")()
+	return s.next.UpdateQuantity(ctx, cartID, cartItemID, quantity)
+}
+
+type productStorage struct {
+	next service.ProductStorage
+	m    *Metrics
+}
+
+func (s *productStorage) Create(ctx context.Context, product *model.Product) error {
+	defer s.m.observe("products", "Create")()
+	return s.next.Create(ctx, product)
+}
+
+func (s *productStorage) Get(ctx context.Context, sku string) (*model.Product, error) {
+	defer s.m.observe("products", "Get")()
+	return s.next.Get(ctx, sku)
+}
+
+func (s *productStorage) List(ctx context.Context) ([]model.Product, error) {
+	defer s.m.observe("products", "List")()
+	return s.next.List(ctx)
+}
+
+func (s *productStorage) Update(ctx context.Context, product *model.Product) error {
+	defer s.m.observe("products", "Update")()
+	return s.next.Update(ctx, product)
+}
+
+func (s *productStorage) Delete(ctx context.Context, sku string) error {
+	defer s.m.observe("products", "Delete")()
+	return s.next.Delete(ctx, sku)
+}
+
+type couponStorage struct {
+	next service.CouponStorage
+	m    *Metrics
+}
+
+func (s *couponStorage) Get(ctx context.Context, code string) (*model.Coupon, error) {
+	defer s.m.observe("coupons", "Get")()
+	return s.next.Get(ctx, code)
+}
+
+func (s *couponStorage) ListForCart(ctx context.Context, cartID string) ([]model.Coupon, error) {
+	defer s.m.observe("coupons", "ListForCart")()
+	return s.next.ListForCart(ctx, cartID)
+}
+
+func (s *couponStorage) Attach(ctx context.Context, cartID, code string) error {
+	defer s.m.observe("coupons", "Attach")()
+	return s.next.Attach(ctx, cartID, code)
+}
+
+func (s *couponStorage) Detach(ctx context.Context, cartID, code string) error {
+	defer s.m.observe("coupons", "Detach")()
+	return s.next.Detach(ctx, cartID, code)
+}
+
+type orderStorage struct {
+	next service.OrderStorage
+	m    *Metrics
+}
+
+func (s *orderStorage) Create(ctx context.Context, order *model.Order) error {
+	defer s.m.observe("orders", "Create")()
+	err := s.next.Create(ctx, order)
+	if err == nil {
+		s.m.count(ctx, s.m.checkouts)
+	}
+	return err
+}
+
+type keyStorage struct {
+	next service.IdempotencyStorage
+	m    *Metrics
+}
+
+func (s *keyStorage) Reserve(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*model.IdempotencyRecord, bool, error) {
+	defer s.m.observe("idempotency_keys", "Reserve")()
+	return s.next.Reserve(ctx, scope, key, requestHash, lockedUntil, expiresAt)
+}
+
+func (s *keyStorage) Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error {
+	defer s.m.observe("idempotency_keys", "Complete")()
+	return s.next.Complete(ctx, scope, key, response)
+}
+
+func (s *keyStorage) Release(ctx context.Context, scope, key string) error {
+	defer s.m.observe("idempotency_keys", "Release")()
+	return s.next.Release(ctx, scope, key)
+}
+
+func (s *keyStorage) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	defer s.m.observe("idempotency_keys", "PurgeExpired")()
+	return s.next.PurgeExpired(ctx, before)
+}
+
+type transactor struct {
+	next service.Transactor
+}
+
+func (t *transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(eventsKey{}).(*events); ok {
+		return t.next.WithinTx(ctx, fn)
+	}
+	e := &events{}
+	if err := t.next.WithinTx(context.WithValue(ctx, eventsKey{}, e), fn); err != nil {
+		return err
+	}
+	for _, c := range e.counters {
+		c.Inc()
+	}
+	return nil
+}
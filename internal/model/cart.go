@@ -1,6 +1,49 @@
 package model
 
+import "time"
+
+// CartStatus is the lifecycle state of a cart.
+type CartStatus string
+
+const (
+	// CartActive carts can be changed freely.
+	CartActive CartStatus = "active"
+	// CartLocked carts are temporarily frozen, e.g. while a payment is in progress.
+	CartLocked CartStatus = "locked"
+	// CartCheckedOut carts have been converted into an order.
+	CartCheckedOut CartStatus = "checked_out"
+	// CartAbandoned carts were left by the shopper and may be resumed.
+	CartAbandoned CartStatus = "abandoned"
+	// CartExpired carts have outlived their lifetime.
+	CartExpired CartStatus = "expired"
+)
+
+// StatusTransition records a change of the cart status.
+type StatusTransition struct {
+	From CartStatus `json:"from" db:"from_status"`
+	To   CartStatus `json:"to" db:"to_status"`
+	At   time.Time  `json:"at" db:"changed_at"`
+}
+
+// Cart is a shopping cart with its items.
+// Subtotal, ItemCount, Currency and the discount fields are computed from the items
+// and the applied coupons when the cart is viewed; amounts are expressed in minor units of Currency.
 type Cart struct {
-	ID    string     `json:"id" db:"id"`
-	Items []CartItem `json:"items" `
+	ID              string             `json:"id" db:"id"`
+	Status          CartStatus         `json:"status" db:"status"`
+	Version         int64              `json:"version" db:"version"`
+	Transitions     []StatusTransition `json:"transitions" db:"-"`
+	CreatedAt       time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" db:"updated_at"`
+	ExpiresAt       time.Time          `json:"expires_at" db:"expires_at"`
+	CheckedOutAt    *time.Time         `json:"checked_out_at,omitempty" db:"checked_out_at"`
+	Items           []CartItem         `json:"items" `
+	Subtotal        int64              `json:"subtotal" db:"-"`
+	ItemCount       int                `json:"item_count" db:"-"`
+	Currency        string             `json:"currency" db:"-"`
+	Discounts       []AppliedDiscount  `json:"discounts" db:"-"`
+	RejectedCoupons []RejectedCoupon   `json:"rejected_coupons,omitempty" db:"-"`
+	DiscountTotal   int64              `json:"discount_total" db:"-"`
+	Total           int64              `json:"total" db:"-"`
+	FreeShipping    bool               `json:"free_shipping" db:"-"`
 }
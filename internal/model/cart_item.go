@@ -1,8 +1,17 @@
 package model
 
+// MaxItemQuantity is the largest quantity a single cart line may have.
+const MaxItemQuantity = 999
+
+// CartItem is a line of a cart.
+// UnitPrice is captured from the catalog when the item is added and is expressed
+// in minor units of Currency; LineTotal is computed and never stored.
 type CartItem struct {
-	ID       string `json:"id" db:"id"`
-	CartID   string `json:"cart_id" db:"cart_id"`
-	Product  string `json:"product"`
-	Quantity int    `json:"quantity"`
+	ID        string `json:"id" db:"id"`
+	CartID    string `json:"cart_id" db:"cart_id"`
+	SKU       string `json:"sku" db:"sku"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int64  `json:"unit_price" db:"unit_price"`
+	Currency  string `json:"currency" db:"currency"`
+	LineTotal int64  `json:"line_total" db:"-"`
 }
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// CouponKind determines how a coupon discounts a cart.
+type CouponKind string
+
+const (
+	// CouponPercentage takes PercentOff percent off the cart subtotal.
+	CouponPercentage CouponKind = "percentage"
+	// CouponFixedAmount takes AmountOff minor units of Currency off the cart subtotal.
+	CouponFixedAmount CouponKind = "fixed_amount"
+	// CouponBuyXGetY makes GetQuantity units of SKU free for every BuyQuantity units bought.
+	CouponBuyXGetY CouponKind = "buy_x_get_y"
+	// CouponFreeShipping waives shipping for the cart.
+	CouponFreeShipping CouponKind = "free_shipping"
+)
+
+// Coupon is a promotion that can be applied to a cart.
+// A nil StartsAt, EndsAt or UsageLimit means the coupon is not restricted in that respect.
+type Coupon struct {
+	Code        string     `json:"code" db:"code"`
+	Kind        CouponKind `json:"kind" db:"kind"`
+	PercentOff  int        `json:"percent_off,omitempty" db:"percent_off"`
+	AmountOff   int64      `json:"amount_off,omitempty" db:"amount_off"`
+	Currency    string     `json:"currency,omitempty" db:"currency"`
+	SKU         string     `json:"sku,omitempty" db:"sku"`
+	BuyQuantity int        `json:"buy_quantity,omitempty" db:"buy_quantity"`
+	GetQuantity int        `json:"get_quantity,omitempty" db:"get_quantity"`
+	MinSubtotal int64      `json:"min_subtotal" db:"min_subtotal"`
+	StartsAt    *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	UsageLimit  *int       `json:"usage_limit,omitempty" db:"usage_limit"`
+	TimesUsed   int        `json:"times_used" db:"times_used"`
+}
+
+// AppliedDiscount describes a coupon that was applied to a cart and the amount it took off.
+type AppliedDiscount struct {
+	Code         string     `json:"code"`
+	Kind         CouponKind `json:"kind"`
+	Amount       int64      `json:"amount"`
+	FreeShipping bool       `json:"free_shipping,omitempty"`
+}
+
+// RejectedCoupon describes a coupon attached to a cart that currently does not apply.
+type RejectedCoupon struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
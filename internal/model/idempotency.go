@@ -0,0 +1,15 @@
+package model
+
+// IdempotencyRecord is a request stored under an idempotency key.
+// Response is nil while the original request is still being processed.
+type IdempotencyRecord struct {
+	RequestHash string
+	Response    *RecordedResponse
+}
+
+// RecordedResponse is the response of a request that is replayed for retries with the same idempotency key.
+type RecordedResponse struct {
+	StatusCode int
+	Header     map[string]string
+	Body       []byte
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Order is an immutable snapshot of a cart taken at checkout.
+// Amounts are expressed in minor units of Currency.
+type Order struct {
+	ID            string            `json:"id" db:"id"`
+	CartID        string            `json:"cart_id" db:"cart_id"`
+	Lines         []OrderLine       `json:"lines"`
+	Currency      string            `json:"currency" db:"currency"`
+	Subtotal      int64             `json:"subtotal" db:"subtotal"`
+	Discounts     []AppliedDiscount `json:"discounts"`
+	DiscountTotal int64             `json:"discount_total" db:"discount_total"`
+	Total         int64             `json:"total" db:"total"`
+	FreeShipping  bool              `json:"free_shipping" db:"free_shipping"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+}
+
+// OrderLine is a cart line as it was priced at checkout.
+type OrderLine struct {
+	SKU       string `json:"sku" db:"sku"`
+	Quantity  int    `json:"quantity" db:"quantity"`
+	UnitPrice int64  `json:"unit_price" db:"unit_price"`
+	LineTotal int64  `json:"line_total" db:"line_total"`
+}
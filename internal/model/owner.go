@@ -0,0 +1,16 @@
+package model
+
+// OwnerKind tells whether a cart belongs to an authenticated user or to an anonymous session.
+type OwnerKind string
+
+const (
+	OwnerUser    OwnerKind = "user"
+	OwnerSession OwnerKind = "session"
+)
+
+// Owner identifies who a cart belongs to.
+// For anonymous sessions ID holds a hash of the session token, never the token itself.
+type Owner struct {
+	Kind OwnerKind `json:"kind" db:"owner_kind"`
+	ID   string    `json:"id" db:"owner_id"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// Product is a catalog entry that can be added to a cart.
+// UnitPrice is expressed in minor units of Currency (e.g. cents for USD).
+type Product struct {
+	SKU       string `json:"sku" db:"sku"`
+	Name      string `json:"name" db:"name"`
+	UnitPrice int64  `json:"unit_price" db:"unit_price"`
+	Currency  string `json:"currency" db:"currency"`
+	Active    bool   `json:"active" db:"active"`
+}
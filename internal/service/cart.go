@@ -1,44 +1,124 @@
 package service
 
 import (
+	"cart-api/internal/carterror"
 	"cart-api/internal/model"
 	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
 )
 
+// tracer starts the spans of the service calls.
+var tracer = otel.Tracer("cart-api/internal/service")
+
 // CartStorage defines the interface for interacting with cart storage
 type CartStorage interface {
-	Create(ctx context.Context) (*model.Cart, error)
+	Create(ctx context.Context, owner model.Owner, ttl time.Duration) (*model.Cart, error)
 	Get(ctx context.Context, id string) (*model.Cart, error)
+	GetOwner(ctx context.Context, id string) (model.Owner, error)
+	Lock(ctx context.Context, id string) (*model.Cart, error)
+	Delete(ctx context.Context, id string) error
+	Clear(ctx context.Context, id string) error
+	SetStatus(ctx context.Context, id string, from, to model.CartStatus) error
+	Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error
 }
 
 // CartService provides business logic for managing carts.
 type CartService struct {
-	repo CartStorage
+	repo    CartStorage
+	coupons CouponStorage
+	tx      Transactor
+	pricer  *Pricer
+	ttl     time.Duration
 }
 
 // NewCartRepository creates a new instance of CartService.
-// It accepts a CartStorage implementation as a dependency.
-func NewCartService(repo CartStorage) *CartService {
-	return &CartService{repo: repo}
+// It accepts CartStorage, CouponStorage and Transactor implementations as dependencies
+// and the lifetime of a cart without changes.
+func NewCartService(repo CartStorage, coupons CouponStorage, tx Transactor, ttl time.Duration) *CartService {
+	return &CartService{repo: repo, coupons: coupons, tx: tx, pricer: NewPricer(), ttl: ttl}
 }
 
-// CreateCart creates a new cart
+// CreateCart creates a new cart owned by owner that expires after the configured lifetime.
 // It delegates the operation to the underlying storage.
-func (s *CartService) CreateCart(ctx context.Context) (*model.Cart, error) {
-	cart, err := s.repo.Create(ctx)
+func (s *CartService) CreateCart(ctx context.Context, owner model.Owner) (*model.Cart, error) {
+	ctx, span := tracer.Start(ctx, "CartService.CreateCart")
+	defer span.End()
+	cart, err := s.repo.Create(ctx, owner, s.ttl)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.pricer.Price(cart, nil); err != nil {
+		return nil, err
+	}
 	return cart, nil
 
 }
 
-// CreateCart retrieves a cart by its ID,including all associated items.
-// It delegates the operation to the underlying storage.
+// CheckOwner verifies that the cart belongs to owner.
+// Foreign carts and carts without an owner are reported as not existing,
+// so that callers cannot tell them apart from unknown cart IDs.
+func (s *CartService) CheckOwner(ctx context.Context, id string, owner model.Owner) error {
+	ctx, span := tracer.Start(ctx, "CartService.CheckOwner")
+	defer span.End()
+	if owner.ID == "" {
+		return carterror.ErrCartDoesNotExist
+	}
+	cartOwner, err := s.repo.GetOwner(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cartOwner != owner {
+		return carterror.ErrCartDoesNotExist
+	}
+	return nil
+}
+
+// ViewCart retrieves a cart by its ID, including all associated items,
+// and computes the line totals, the cart subtotal and the discounts of the attached coupons.
 func (s *CartService) ViewCart(ctx context.Context, id string) (*model.Cart, error) {
+	ctx, span := tracer.Start(ctx, "CartService.ViewCart")
+	defer span.End()
 	cart, err := s.repo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	coupons, err := s.coupons.ListForCart(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pricer.Price(cart, coupons); err != nil {
+		return nil, err
+	}
 	return cart, nil
 }
+
+// DeleteCart deletes a cart by its ID together with all of its items.
+// Checked out carts are kept as the record of their order and cannot be deleted.
+func (s *CartService) DeleteCart(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "CartService.DeleteCart")
+	defer span.End()
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cart, err := s.repo.Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if cart.Status == model.CartCheckedOut {
+			return &carterror.StatusError{Status: string(cart.Status)}
+		}
+		return s.repo.Delete(ctx, id)
+	})
+}
+
+// ClearCart removes all items from an active cart, keeping the cart itself.
+func (s *CartService) ClearCart(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "CartService.ClearCart")
+	defer span.End()
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockActive(ctx, s.repo, id); err != nil {
+			return err
+		}
+		return s.repo.Clear(ctx, id)
+	})
+}
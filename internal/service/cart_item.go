@@ -8,43 +8,117 @@ import (
 
 // CartItemStorage defines the interface for interacting with cart item storage.
 type CartItemStorage interface {
-	Create(ctx context.Context, item *model.CartItem) error
+	Create(ctx context.Context, item *model.CartItem, maxQuantity int) (bool, error)
 	Delete(ctx context.Context, CartID, CartItemID string) error
+	UpdateQuantity(ctx context.Context, CartID, CartItemID string, quantity int) (*model.CartItem, error)
+}
+
+// ProductCatalog defines the product lookup used to validate items added to a cart.
+type ProductCatalog interface {
+	Get(ctx context.Context, sku string) (*model.Product, error)
+}
+
+// CartLocker defines the cart lookup used to check the status of a cart before it is changed.
+type CartLocker interface {
+	Lock(ctx context.Context, id string) (*model.Cart, error)
 }
 
 // CartItemService provides business logic for managing cart items.
+// Items can only be changed while their cart is active.
+// The changes take an optional ifMatch precondition, which must accept the version of the cart
+// for the change to be made; otherwise a carterror.VersionError is returned.
 type CartItemService struct {
-	repo CartItemStorage
+	repo     CartItemStorage
+	carts    CartLocker
+	products ProductCatalog
+	tx       Transactor
 }
 
 // NewCartItemRepository creates a new instance of CartItemService.
-// It accepts a CartItemStorage implementation as a dependency.
-func NewCartItemRepository(repo CartItemStorage) *CartItemService {
-	return &CartItemService{repo: repo}
+// It accepts CartItemStorage, CartLocker, ProductCatalog and Transactor implementations as dependencies.
+func NewCartItemRepository(repo CartItemStorage, carts CartLocker, products ProductCatalog, tx Transactor) *CartItemService {
+	return &CartItemService{repo: repo, carts: carts, products: products, tx: tx}
 }
 
-// AddToCart adds a new item to the cart.
-// It delegates the operation to the underlying storage.
-func (s CartItemService) AddToCart(ctx context.Context, item *model.CartItem) error {
-	if item.Product == "" {
-		return carterror.ErrMissingProduct
+// AddToCart adds an item to the cart.
+// Adding a product that is already in the cart increases the quantity of the existing line;
+// the returned flag reports whether such a merge happened.
+// Only active products of the catalog can be added; the line keeps the catalog price at the time of adding.
+func (s CartItemService) AddToCart(ctx context.Context, item *model.CartItem, ifMatch func(version int64) bool) (bool, error) {
+	ctx, span := tracer.Start(ctx, "CartItemService.AddToCart")
+	defer span.End()
+	if item.SKU == "" {
+		return false, carterror.ErrMissingSKU
 	}
-	if item.Quantity < 0 {
-		return carterror.ErrQuantityMustBePositive
+	if item.Quantity <= 0 {
+		return false, carterror.ErrQuantityMustBePositive
 	}
-	err := s.repo.Create(ctx, item)
+	if item.Quantity > model.MaxItemQuantity {
+		return false, carterror.ErrQuantityTooLarge
+	}
+	product, err := s.products.Get(ctx, item.SKU)
 	if err != nil {
+		return false, err
+	}
+	if !product.Active {
+		return false, carterror.ErrProductInactive
+	}
+	item.UnitPrice = product.UnitPrice
+	item.Currency = product.Currency
+	var merged bool
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockMatching(ctx, s.carts, item.CartID, ifMatch); err != nil {
+			return err
+		}
+		var err error
+		merged, err = s.repo.Create(ctx, item, model.MaxItemQuantity)
 		return err
+	})
+	if err != nil {
+		return false, err
 	}
-	return nil
+	priceLine(item)
+	return merged, nil
 }
 
 // RemoveFromCart removes an item from the cart by its ID and cart ID.
-// It delegates the operation to the underlying storage.
-func (s CartItemService) RemoveFromCart(ctx context.Context, CartID, CartItemID string) error {
-	err := s.repo.Delete(ctx, CartID, CartItemID)
-	if err != nil {
+func (s CartItemService) RemoveFromCart(ctx context.Context, CartID, CartItemID string, ifMatch func(version int64) bool) error {
+	ctx, span := tracer.Start(ctx, "CartItemService.RemoveFromCart")
+	defer span.End()
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockMatching(ctx, s.carts, CartID, ifMatch); err != nil {
+			return err
+		}
+		return s.repo.Delete(ctx, CartID, CartItemID)
+	})
+}
+
+// UpdateQuantity changes the quantity of an item in the cart in place.
+// A quantity of zero removes the item, in which case a nil item is returned.
+func (s CartItemService) UpdateQuantity(ctx context.Context, CartID, CartItemID string, quantity int, ifMatch func(version int64) bool) (*model.CartItem, error) {
+	ctx, span := tracer.Start(ctx, "CartItemService.UpdateQuantity")
+	defer span.End()
+	if quantity < 0 {
+		return nil, carterror.ErrQuantityMustBePositive
+	}
+	if quantity > model.MaxItemQuantity {
+		return nil, carterror.ErrQuantityTooLarge
+	}
+	var item *model.CartItem
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockMatching(ctx, s.carts, CartID, ifMatch); err != nil {
+			return err
+		}
+		if quantity == 0 {
+			return s.repo.Delete(ctx, CartID, CartItemID)
+		}
+		var err error
+		item, err = s.repo.UpdateQuantity(ctx, CartID, CartItemID, quantity)
 		return err
+	})
+	if err != nil || item == nil {
+		return nil, err
 	}
-	return err
+	priceLine(item)
+	return item, nil
 }
@@ -1,6 +1,7 @@
 package service_test
 
 import (
+	"cart-api/internal/carterror"
 	"cart-api/internal/model"
 	"cart-api/internal/service"
 	"context"
@@ -11,33 +12,84 @@ import (
 )
 
 type mockCartItemStorage struct {
-	createErr error
-	deleteErr error
+	createCalled bool
+	createMerged bool
+	createErr    error
+	deleteErr    error
+	deleteCalled bool
+	updateResult *model.CartItem
+	updateErr    error
 }
 
-func (m *mockCartItemStorage) Create(ctx context.Context, item *model.CartItem) error {
-	return m.createErr
+func (m *mockCartItemStorage) Create(ctx context.Context, item *model.CartItem, maxQuantity int) (bool, error) {
+	m.createCalled = true
+	return m.createMerged, m.createErr
 }
 
 func (m *mockCartItemStorage) Delete(ctx context.Context, cartID, cartItemID string) error {
+	m.deleteCalled = true
 	return m.deleteErr
 }
 
+func (m *mockCartItemStorage) UpdateQuantity(ctx context.Context, cartID, cartItemID string, quantity int) (*model.CartItem, error) {
+	return m.updateResult, m.updateErr
+}
+
+type mockProductCatalog struct {
+	product *model.Product
+	err     error
+}
+
+func (m *mockProductCatalog) Get(ctx context.Context, sku string) (*model.Product, error) {
+	return m.product, m.err
+}
+
+var activeCatalog = &mockProductCatalog{
+	product: &model.Product{SKU: "product1", Name: "Product", UnitPrice: 100, Currency: "USD", Active: true},
+}
+
+// activeCart returns cart storage holding an active cart.
+func activeCart() *mockCartStorage {
+	return &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartActive}}
+}
+
 func TestAddToCart_Success(t *testing.T) {
 	mockRepo := &mockCartItemStorage{
 		createErr: nil,
 	}
 
-	service := service.NewCartItemRepository(mockRepo)
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
+
+	item := &model.CartItem{
+		CartID:   "cart-id",
+		SKU:      "product1",
+		Quantity: 2,
+	}
+
+	merged, err := service.AddToCart(context.Background(), item, nil)
+	assert.NoError(t, err)
+	assert.False(t, merged)
+	assert.Equal(t, int64(100), item.UnitPrice)
+	assert.Equal(t, "USD", item.Currency)
+	assert.Equal(t, int64(200), item.LineTotal)
+}
+
+func TestAddToCart_Merged(t *testing.T) {
+	mockRepo := &mockCartItemStorage{
+		createMerged: true,
+	}
+
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
 
 	item := &model.CartItem{
 		CartID:   "cart-id",
-		Product:  "product1",
+		SKU:      "product1",
 		Quantity: 2,
 	}
 
-	err := service.AddToCart(context.Background(), item)
+	merged, err := service.AddToCart(context.Background(), item, nil)
 	assert.NoError(t, err)
+	assert.True(t, merged)
 }
 
 func TestAddToCart_Error(t *testing.T) {
@@ -45,27 +97,93 @@ func TestAddToCart_Error(t *testing.T) {
 		createErr: errors.New("failed to add item to cart"),
 	}
 
-	service := service.NewCartItemRepository(mockRepo)
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
 
 	item := &model.CartItem{
 		CartID:   "cart-id",
-		Product:  "product1",
+		SKU:      "product1",
 		Quantity: 2,
 	}
 
-	err := service.AddToCart(context.Background(), item)
+	_, err := service.AddToCart(context.Background(), item, nil)
 	assert.Error(t, err)
 	assert.Equal(t, "failed to add item to cart", err.Error())
 }
 
+func TestAddToCart_UnknownProduct(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	catalog := &mockProductCatalog{err: carterror.ErrProductDoesNotExist}
+
+	service := service.NewCartItemRepository(mockRepo, activeCart(), catalog, &mockTransactor{})
+
+	item := &model.CartItem{CartID: "cart-id", SKU: "missing", Quantity: 1}
+
+	_, err := service.AddToCart(context.Background(), item, nil)
+	assert.ErrorIs(t, err, carterror.ErrProductDoesNotExist)
+}
+
+func TestAddToCart_InactiveProduct(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	catalog := &mockProductCatalog{product: &model.Product{SKU: "product1", Active: false}}
+
+	service := service.NewCartItemRepository(mockRepo, activeCart(), catalog, &mockTransactor{})
+
+	item := &model.CartItem{CartID: "cart-id", SKU: "product1", Quantity: 1}
+
+	_, err := service.AddToCart(context.Background(), item, nil)
+	assert.ErrorIs(t, err, carterror.ErrProductInactive)
+}
+
+func TestAddToCart_InvalidQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity int
+		wantErr  error
+	}{
+		{"zero", 0, carterror.ErrQuantityMustBePositive},
+		{"negative", -1, carterror.ErrQuantityMustBePositive},
+		{"too large", model.MaxItemQuantity + 1, carterror.ErrQuantityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockCartItemStorage{}
+
+			service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
+
+			item := &model.CartItem{CartID: "cart-id", SKU: "product1", Quantity: tt.quantity}
+
+			_, err := service.AddToCart(context.Background(), item, nil)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAddToCart_InactiveCart(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartLocked}}
+	tx := &mockTransactor{}
+
+	service := service.NewCartItemRepository(mockRepo, carts, activeCatalog, tx)
+
+	item := &model.CartItem{CartID: "cart-id", SKU: "product1", Quantity: 1}
+
+	_, err := service.AddToCart(context.Background(), item, nil)
+	assert.ErrorIs(t, err, carterror.ErrCartNotActive)
+	assert.EqualError(t, err, "cart is locked")
+	assert.Equal(t, 1, tx.calls)
+	assert.True(t, carts.locked)
+	assert.False(t, mockRepo.createCalled)
+}
+
 func TestRemoveFromCart_Success(t *testing.T) {
 	mockRepo := &mockCartItemStorage{
 		deleteErr: nil,
 	}
 
-	service := service.NewCartItemRepository(mockRepo)
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
 
-	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id")
+	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id", nil)
 	assert.NoError(t, err)
 }
 
@@ -74,9 +192,94 @@ func TestRemoveFromCart_Error(t *testing.T) {
 		deleteErr: errors.New("failed to remove item from cart"),
 	}
 
-	service := service.NewCartItemRepository(mockRepo)
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
 
-	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id")
+	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id", nil)
 	assert.Error(t, err)
 	assert.Equal(t, "failed to remove item from cart", err.Error())
 }
+
+func TestRemoveFromCart_CheckedOutCart(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartCheckedOut}}
+
+	service := service.NewCartItemRepository(mockRepo, carts, activeCatalog, &mockTransactor{})
+
+	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id", nil)
+	assert.ErrorIs(t, err, carterror.ErrCartCheckedOut)
+	assert.ErrorIs(t, err, carterror.ErrCartNotActive)
+	assert.False(t, mockRepo.deleteCalled)
+}
+
+func TestRemoveFromCart_IfMatch(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartActive, Version: 3}}
+
+	service := service.NewCartItemRepository(mockRepo, carts, activeCatalog, &mockTransactor{})
+
+	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id", func(version int64) bool { return version == 3 })
+	assert.NoError(t, err)
+	assert.True(t, mockRepo.deleteCalled)
+}
+
+func TestRemoveFromCart_StaleVersion(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartActive, Version: 3}}
+
+	service := service.NewCartItemRepository(mockRepo, carts, activeCatalog, &mockTransactor{})
+
+	err := service.RemoveFromCart(context.Background(), "cart-id", "item-id", func(version int64) bool { return version == 2 })
+	assert.ErrorIs(t, err, carterror.ErrPreconditionFailed)
+	var versionErr *carterror.VersionError
+	if assert.ErrorAs(t, err, &versionErr) {
+		assert.Equal(t, int64(3), versionErr.Version)
+	}
+	assert.True(t, carts.locked)
+	assert.False(t, mockRepo.deleteCalled)
+}
+
+func TestAddToCart_StaleVersion(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartActive, Version: 3}}
+
+	service := service.NewCartItemRepository(mockRepo, carts, activeCatalog, &mockTransactor{})
+
+	item := &model.CartItem{CartID: "cart-id", SKU: "product1", Quantity: 1}
+	_, err := service.AddToCart(context.Background(), item, func(version int64) bool { return false })
+	assert.ErrorIs(t, err, carterror.ErrPreconditionFailed)
+	assert.False(t, mockRepo.createCalled)
+}
+
+func TestUpdateQuantity_Success(t *testing.T) {
+	mockRepo := &mockCartItemStorage{
+		updateResult: &model.CartItem{ID: "item-id", CartID: "cart-id", SKU: "product1", Quantity: 5},
+	}
+
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
+
+	item, err := service.UpdateQuantity(context.Background(), "cart-id", "item-id", 5, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, item.Quantity)
+	assert.False(t, mockRepo.deleteCalled)
+}
+
+func TestUpdateQuantity_ZeroRemovesItem(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
+
+	item, err := service.UpdateQuantity(context.Background(), "cart-id", "item-id", 0, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, item)
+	assert.True(t, mockRepo.deleteCalled)
+}
+
+func TestUpdateQuantity_Negative(t *testing.T) {
+	mockRepo := &mockCartItemStorage{}
+
+	service := service.NewCartItemRepository(mockRepo, activeCart(), activeCatalog, &mockTransactor{})
+
+	item, err := service.UpdateQuantity(context.Background(), "cart-id", "item-id", -1, nil)
+	assert.ErrorIs(t, err, carterror.ErrQuantityMustBePositive)
+	assert.Nil(t, item)
+}
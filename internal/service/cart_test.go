@@ -1,39 +1,118 @@
 package service_test
 
 import (
+	"cart-api/internal/carterror"
 	"cart-api/internal/model"
 	"cart-api/internal/service"
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// mockTransactor runs units of work directly and counts them.
+type mockTransactor struct {
+	calls int
+}
+
+func (m *mockTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	return fn(ctx)
+}
+
 type mockCartStorage struct {
+	locked           bool
+	owner            model.Owner
+	getOwnerErr      error
 	createCartResult *model.Cart
 	createCartErr    error
 	getCartResult    *model.Cart
 	getCartErr       error
+	deleteErr        error
+	clearErr         error
+	setStatusErr     error
+	setStatus        model.CartStatus
+	mergeErr         error
+	mergedSource     string
+	mergeMax         int
 }
 
-func (m *mockCartStorage) Create(ctx context.Context) (*model.Cart, error) {
+func (m *mockCartStorage) Create(ctx context.Context, owner model.Owner, ttl time.Duration) (*model.Cart, error) {
+	m.owner = owner
 	return m.createCartResult, m.createCartErr
 }
 
+func (m *mockCartStorage) GetOwner(ctx context.Context, id string) (model.Owner, error) {
+	return m.owner, m.getOwnerErr
+}
+
+func (m *mockCartStorage) Lock(ctx context.Context, id string) (*model.Cart, error) {
+	m.locked = true
+	return m.getCartResult, m.getCartErr
+}
+
 func (m *mockCartStorage) Get(ctx context.Context, id string) (*model.Cart, error) {
 	return m.getCartResult, m.getCartErr
 }
 
+func (m *mockCartStorage) Delete(ctx context.Context, id string) error {
+	return m.deleteErr
+}
+
+func (m *mockCartStorage) Clear(ctx context.Context, id string) error {
+	return m.clearErr
+}
+
+func (m *mockCartStorage) SetStatus(ctx context.Context, id string, from, to model.CartStatus) error {
+	m.setStatus = to
+	return m.setStatusErr
+}
+
+func (m *mockCartStorage) Merge(ctx context.Context, targetID, sourceID string, maxQuantity int) error {
+	m.mergedSource = sourceID
+	m.mergeMax = maxQuantity
+	return m.mergeErr
+}
+
+type mockCouponStorage struct {
+	coupon    *model.Coupon
+	getErr    error
+	attached  []model.Coupon
+	attachErr error
+	detachErr error
+}
+
+func (m *mockCouponStorage) Get(ctx context.Context, code string) (*model.Coupon, error) {
+	return m.coupon, m.getErr
+}
+
+func (m *mockCouponStorage) ListForCart(ctx context.Context, cartID string) ([]model.Coupon, error) {
+	return m.attached, nil
+}
+
+func (m *mockCouponStorage) Attach(ctx context.Context, cartID, code string) error {
+	if m.attachErr != nil {
+		return m.attachErr
+	}
+	m.attached = append(m.attached, *m.coupon)
+	return nil
+}
+
+func (m *mockCouponStorage) Detach(ctx context.Context, cartID, code string) error {
+	return m.detachErr
+}
+
 func TestCreateCart_Success(t *testing.T) {
 	mockRepo := &mockCartStorage{
 		createCartResult: &model.Cart{ID: "cart-id"},
 		createCartErr:    nil,
 	}
 
-	service := service.NewCartService(mockRepo)
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
 
-	cart, err := service.CreateCart(context.Background())
+	cart, err := service.CreateCart(context.Background(), model.Owner{Kind: model.OwnerSession, ID: "session"})
 	assert.NoError(t, err)
 	assert.NotNil(t, cart)
 	assert.Equal(t, "cart-id", cart.ID)
@@ -45,9 +124,9 @@ func TestCreateCart_Error(t *testing.T) {
 		createCartErr:    errors.New("failed to create cart"),
 	}
 
-	service := service.NewCartService(mockRepo)
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
 
-	cart, err := service.CreateCart(context.Background())
+	cart, err := service.CreateCart(context.Background(), model.Owner{Kind: model.OwnerSession, ID: "session"})
 	assert.Error(t, err)
 	assert.Nil(t, cart)
 	assert.Equal(t, "failed to create cart", err.Error())
@@ -59,7 +138,7 @@ func TestViewCart_Success(t *testing.T) {
 		getCartErr:    nil,
 	}
 
-	service := service.NewCartService(mockRepo)
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
 
 	cart, err := service.ViewCart(context.Background(), "cart-id")
 	assert.NoError(t, err)
@@ -67,16 +146,128 @@ func TestViewCart_Success(t *testing.T) {
 	assert.Equal(t, "cart-id", cart.ID)
 }
 
+func TestViewCart_ComputesTotals(t *testing.T) {
+	mockRepo := &mockCartStorage{
+		getCartResult: &model.Cart{ID: "cart-id", Items: []model.CartItem{
+			{SKU: "A", Quantity: 2, UnitPrice: 250, Currency: "USD"},
+		}},
+	}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	cart, err := service.ViewCart(context.Background(), "cart-id")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(500), cart.Items[0].LineTotal)
+	assert.Equal(t, int64(500), cart.Subtotal)
+	assert.Equal(t, 2, cart.ItemCount)
+	assert.Equal(t, "USD", cart.Currency)
+}
+
 func TestViewCart_Error(t *testing.T) {
 	mockRepo := &mockCartStorage{
 		getCartResult: nil,
 		getCartErr:    errors.New("cart not found"),
 	}
 
-	service := service.NewCartService(mockRepo)
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
 
 	cart, err := service.ViewCart(context.Background(), "cart-id")
 	assert.Error(t, err)
 	assert.Nil(t, cart)
 	assert.Equal(t, "cart not found", err.Error())
 }
+
+func TestDeleteCart_Success(t *testing.T) {
+	mockRepo := activeCart()
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	err := service.DeleteCart(context.Background(), "cart-id")
+	assert.NoError(t, err)
+	assert.True(t, mockRepo.locked)
+}
+
+func TestDeleteCart_NotFound(t *testing.T) {
+	mockRepo := &mockCartStorage{
+		getCartErr: carterror.ErrCartDoesNotExist,
+	}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	err := service.DeleteCart(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
+
+func TestDeleteCart_CheckedOut(t *testing.T) {
+	mockRepo := &mockCartStorage{
+		getCartResult: &model.Cart{ID: "cart-id", Status: model.CartCheckedOut},
+		deleteErr:     errors.New("checked out cart was deleted"),
+	}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	err := service.DeleteCart(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartCheckedOut)
+}
+
+func TestClearCart_Success(t *testing.T) {
+	mockRepo := activeCart()
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	err := service.ClearCart(context.Background(), "cart-id")
+	assert.NoError(t, err)
+}
+
+func TestClearCart_NotFound(t *testing.T) {
+	mockRepo := &mockCartStorage{
+		getCartErr: carterror.ErrCartDoesNotExist,
+	}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	err := service.ClearCart(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
+
+func TestClearCart_InactiveCart(t *testing.T) {
+	mockRepo := &mockCartStorage{
+		getCartResult: &model.Cart{ID: "cart-id", Status: model.CartExpired},
+		clearErr:      errors.New("cleared an inactive cart"),
+	}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	err := service.ClearCart(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartNotActive)
+	assert.EqualError(t, err, "cart is expired")
+}
+
+func TestCheckOwner(t *testing.T) {
+	owner := model.Owner{Kind: model.OwnerUser, ID: "user-1"}
+	tests := []struct {
+		name      string
+		cartOwner model.Owner
+		caller    model.Owner
+		wantErr   error
+	}{
+		{"owner", owner, owner, nil},
+		{"other user", owner, model.Owner{Kind: model.OwnerUser, ID: "user-2"}, carterror.ErrCartDoesNotExist},
+		{"session with the same id", owner, model.Owner{Kind: model.OwnerSession, ID: "user-1"}, carterror.ErrCartDoesNotExist},
+		{"cart without owner", model.Owner{}, model.Owner{}, carterror.ErrCartDoesNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockCartStorage{owner: tt.cartOwner}
+			service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+			err := service.CheckOwner(context.Background(), "cart-id", tt.caller)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+)
+
+// CouponStorage defines the interface for interacting with coupon storage.
+type CouponStorage interface {
+	Get(ctx context.Context, code string) (*model.Coupon, error)
+	ListForCart(ctx context.Context, cartID string) ([]model.Coupon, error)
+	Attach(ctx context.Context, cartID, code string) error
+	Detach(ctx context.Context, cartID, code string) error
+}
+
+// ApplyCoupon attaches a coupon to an active cart and returns the repriced cart.
+// The coupon is only attached if it currently applies to the cart;
+// otherwise the reason it was rejected is returned as an error.
+func (s *CartService) ApplyCoupon(ctx context.Context, cartID, code string) (*model.Cart, error) {
+	ctx, span := tracer.Start(ctx, "CartService.ApplyCoupon")
+	defer span.End()
+	if code == "" {
+		return nil, carterror.ErrMissingCouponCode
+	}
+	var cart *model.Cart
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockActive(ctx, s.repo, cartID); err != nil {
+			return err
+		}
+		current, err := s.ViewCart(ctx, cartID)
+		if err != nil {
+			return err
+		}
+		coupon, err := s.coupons.Get(ctx, code)
+		if err != nil {
+			return err
+		}
+		if _, err := s.pricer.discounts.Evaluate(current, *coupon); err != nil {
+			return err
+		}
+		if err := s.coupons.Attach(ctx, cartID, code); err != nil {
+			return err
+		}
+		cart, err = s.ViewCart(ctx, cartID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// RemoveCoupon detaches a coupon from an active cart.
+func (s *CartService) RemoveCoupon(ctx context.Context, cartID, code string) error {
+	ctx, span := tracer.Start(ctx, "CartService.RemoveCoupon")
+	defer span.End()
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockActive(ctx, s.repo, cartID); err != nil {
+			return err
+		}
+		return s.coupons.Detach(ctx, cartID, code)
+	})
+}
@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cartWithSubtotal(subtotal int64) *model.Cart {
+	return &model.Cart{ID: "cart-id", Status: model.CartActive, Items: []model.CartItem{
+		{SKU: "A", Quantity: 1, UnitPrice: subtotal, Currency: "USD"},
+	}}
+}
+
+func TestApplyCoupon_Success(t *testing.T) {
+	mockRepo := &mockCartStorage{getCartResult: cartWithSubtotal(1000)}
+	coupons := &mockCouponStorage{coupon: &model.Coupon{Code: "TEN", Kind: model.CouponPercentage, PercentOff: 10}}
+
+	service := service.NewCartService(mockRepo, coupons, &mockTransactor{}, time.Hour)
+
+	cart, err := service.ApplyCoupon(context.Background(), "cart-id", "TEN")
+	assert.NoError(t, err)
+	assert.Len(t, cart.Discounts, 1)
+	assert.Equal(t, int64(100), cart.DiscountTotal)
+	assert.Equal(t, int64(900), cart.Total)
+}
+
+func TestApplyCoupon_Rejected(t *testing.T) {
+	mockRepo := &mockCartStorage{getCartResult: cartWithSubtotal(1000)}
+	coupons := &mockCouponStorage{coupon: &model.Coupon{Code: "BIG", Kind: model.CouponPercentage, PercentOff: 10, MinSubtotal: 5000}}
+
+	service := service.NewCartService(mockRepo, coupons, &mockTransactor{}, time.Hour)
+
+	cart, err := service.ApplyCoupon(context.Background(), "cart-id", "BIG")
+	assert.ErrorIs(t, err, carterror.ErrCouponBelowMinimum)
+	assert.Nil(t, cart)
+	assert.Empty(t, coupons.attached)
+}
+
+func TestApplyCoupon_UnknownCoupon(t *testing.T) {
+	mockRepo := &mockCartStorage{getCartResult: cartWithSubtotal(1000)}
+	coupons := &mockCouponStorage{getErr: carterror.ErrCouponDoesNotExist}
+
+	service := service.NewCartService(mockRepo, coupons, &mockTransactor{}, time.Hour)
+
+	_, err := service.ApplyCoupon(context.Background(), "cart-id", "NOPE")
+	assert.ErrorIs(t, err, carterror.ErrCouponDoesNotExist)
+}
+
+func TestViewCart_ReportsRejectedCoupons(t *testing.T) {
+	limit := 1
+	mockRepo := &mockCartStorage{getCartResult: cartWithSubtotal(1000)}
+	coupons := &mockCouponStorage{attached: []model.Coupon{
+		{Code: "USED", Kind: model.CouponPercentage, PercentOff: 10, UsageLimit: &limit, TimesUsed: 1},
+	}}
+
+	service := service.NewCartService(mockRepo, coupons, &mockTransactor{}, time.Hour)
+
+	cart, err := service.ViewCart(context.Background(), "cart-id")
+	assert.NoError(t, err)
+	assert.Empty(t, cart.Discounts)
+	assert.Equal(t, []model.RejectedCoupon{{Code: "USED", Reason: carterror.ErrCouponExhausted.Error()}}, cart.RejectedCoupons)
+	assert.Equal(t, int64(1000), cart.Total)
+}
+
+func TestRemoveCoupon_NotApplied(t *testing.T) {
+	coupons := &mockCouponStorage{detachErr: carterror.ErrCouponNotApplied}
+
+	service := service.NewCartService(activeCart(), coupons, &mockTransactor{}, time.Hour)
+
+	err := service.RemoveCoupon(context.Background(), "cart-id", "TEN")
+	assert.ErrorIs(t, err, carterror.ErrCouponNotApplied)
+}
+
+func TestApplyCoupon_CheckedOutCart(t *testing.T) {
+	cart := cartWithSubtotal(1000)
+	cart.Status = model.CartCheckedOut
+	coupons := &mockCouponStorage{coupon: &model.Coupon{Code: "TEN", Kind: model.CouponPercentage, PercentOff: 10}}
+
+	service := service.NewCartService(&mockCartStorage{getCartResult: cart}, coupons, &mockTransactor{}, time.Hour)
+
+	_, err := service.ApplyCoupon(context.Background(), "cart-id", "TEN")
+	assert.ErrorIs(t, err, carterror.ErrCartCheckedOut)
+	assert.Empty(t, coupons.attached)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"time"
+)
+
+// DiscountEngine evaluates coupons against a priced cart.
+type DiscountEngine struct {
+	now func() time.Time
+}
+
+// NewDiscountEngine creates a new instance of DiscountEngine that evaluates validity windows against the current time.
+func NewDiscountEngine() *DiscountEngine {
+	return &DiscountEngine{now: time.Now}
+}
+
+// Apply evaluates every coupon against the cart, recording applied discounts and rejected coupons
+// with the reason they were rejected, and computes the discount total and the cart total.
+// The cart subtotal must already be computed.
+func (e *DiscountEngine) Apply(cart *model.Cart, coupons []model.Coupon) {
+	cart.Discounts = []model.AppliedDiscount{}
+	cart.RejectedCoupons = nil
+	cart.DiscountTotal = 0
+	cart.FreeShipping = false
+
+	for _, coupon := range coupons {
+		discount, err := e.Evaluate(cart, coupon)
+		if err != nil {
+			cart.RejectedCoupons = append(cart.RejectedCoupons, model.RejectedCoupon{Code: coupon.Code, Reason: err.Error()})
+			continue
+		}
+		cart.Discounts = append(cart.Discounts, discount)
+		cart.DiscountTotal += discount.Amount
+		cart.FreeShipping = cart.FreeShipping || discount.FreeShipping
+	}
+
+	if cart.DiscountTotal > cart.Subtotal {
+		cart.DiscountTotal = cart.Subtotal
+	}
+	cart.Total = cart.Subtotal - cart.DiscountTotal
+}
+
+// Evaluate computes the discount a single coupon gives on the cart.
+// It returns an error describing why the coupon is rejected if it does not apply.
+func (e *DiscountEngine) Evaluate(cart *model.Cart, coupon model.Coupon) (model.AppliedDiscount, error) {
+	discount := model.AppliedDiscount{Code: coupon.Code, Kind: coupon.Kind}
+
+	now := e.now()
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return discount, carterror.ErrCouponNotYetValid
+	}
+	if coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return discount, carterror.ErrCouponExpired
+	}
+	if coupon.UsageLimit != nil && coupon.TimesUsed >= *coupon.UsageLimit {
+		return discount, carterror.ErrCouponExhausted
+	}
+	if cart.Subtotal < coupon.MinSubtotal {
+		return discount, carterror.ErrCouponBelowMinimum
+	}
+
+	switch coupon.Kind {
+	case model.CouponPercentage:
+		discount.Amount = cart.Subtotal * int64(coupon.PercentOff) / 100
+	case model.CouponFixedAmount:
+		if cart.Currency != "" && cart.Currency != coupon.Currency {
+			return discount, carterror.ErrCurrencyMismatch
+		}
+		discount.Amount = min(coupon.AmountOff, cart.Subtotal)
+	case model.CouponBuyXGetY:
+		discount.Amount = buyXGetYAmount(cart, coupon)
+		if discount.Amount == 0 {
+			return discount, carterror.ErrCouponNotApplicable
+		}
+	case model.CouponFreeShipping:
+		discount.FreeShipping = true
+	default:
+		return discount, carterror.ErrCouponNotApplicable
+	}
+	return discount, nil
+}
+
+// buyXGetYAmount computes the value of the free units of the coupon SKU in the cart.
+func buyXGetYAmount(cart *model.Cart, coupon model.Coupon) int64 {
+	group := coupon.BuyQuantity + coupon.GetQuantity
+	if group <= 0 {
+		return 0
+	}
+	var amount int64
+	for _, item := range cart.Items {
+		if item.SKU != coupon.SKU {
+			continue
+		}
+		free := item.Quantity / group * coupon.GetQuantity
+		amount += int64(free) * item.UnitPrice
+	}
+	return amount
+}
@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pricedCart(items ...model.CartItem) *model.Cart {
+	cart := &model.Cart{ID: "cart-id", Items: items}
+	for _, item := range items {
+		cart.Subtotal += item.UnitPrice * int64(item.Quantity)
+		cart.Currency = item.Currency
+	}
+	return cart
+}
+
+func TestEvaluate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	limit := 5
+
+	cart := pricedCart(
+		model.CartItem{SKU: "SOCKS", Quantity: 5, UnitPrice: 300, Currency: "USD"},
+		model.CartItem{SKU: "SHOES", Quantity: 1, UnitPrice: 5000, Currency: "USD"},
+	)
+
+	tests := []struct {
+		name         string
+		coupon       model.Coupon
+		wantAmount   int64
+		wantShipping bool
+		wantErr      error
+	}{
+		{"percentage", model.Coupon{Kind: model.CouponPercentage, PercentOff: 10}, 650, false, nil},
+		{"fixed amount", model.Coupon{Kind: model.CouponFixedAmount, AmountOff: 1000, Currency: "USD"}, 1000, false, nil},
+		{"fixed amount capped at subtotal", model.Coupon{Kind: model.CouponFixedAmount, AmountOff: 100000, Currency: "USD"}, 6500, false, nil},
+		{"fixed amount other currency", model.Coupon{Kind: model.CouponFixedAmount, AmountOff: 1000, Currency: "EUR"}, 0, false, carterror.ErrCurrencyMismatch},
+		{"buy two get one", model.Coupon{Kind: model.CouponBuyXGetY, SKU: "SOCKS", BuyQuantity: 2, GetQuantity: 1}, 300, false, nil},
+		{"buy x get y without sku", model.Coupon{Kind: model.CouponBuyXGetY, SKU: "HAT", BuyQuantity: 2, GetQuantity: 1}, 0, false, carterror.ErrCouponNotApplicable},
+		{"free shipping", model.Coupon{Kind: model.CouponFreeShipping}, 0, true, nil},
+		{"expired", model.Coupon{Kind: model.CouponPercentage, PercentOff: 10, EndsAt: &past}, 0, false, carterror.ErrCouponExpired},
+		{"not yet valid", model.Coupon{Kind: model.CouponPercentage, PercentOff: 10, StartsAt: &future}, 0, false, carterror.ErrCouponNotYetValid},
+		{"within window", model.Coupon{Kind: model.CouponPercentage, PercentOff: 10, StartsAt: &past, EndsAt: &future}, 650, false, nil},
+		{"exhausted", model.Coupon{Kind: model.CouponPercentage, PercentOff: 10, UsageLimit: &limit, TimesUsed: 5}, 0, false, carterror.ErrCouponExhausted},
+		{"below minimum", model.Coupon{Kind: model.CouponPercentage, PercentOff: 10, MinSubtotal: 10000}, 0, false, carterror.ErrCouponBelowMinimum},
+	}
+
+	engine := service.NewDiscountEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount, err := engine.Evaluate(cart, tt.coupon)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAmount, discount.Amount)
+			assert.Equal(t, tt.wantShipping, discount.FreeShipping)
+		})
+	}
+}
+
+func TestApply_StacksAndClampsTotal(t *testing.T) {
+	cart := pricedCart(model.CartItem{SKU: "SHOES", Quantity: 1, UnitPrice: 1000, Currency: "USD"})
+
+	service.NewDiscountEngine().Apply(cart, []model.Coupon{
+		{Code: "HALF", Kind: model.CouponPercentage, PercentOff: 50},
+		{Code: "TENNER", Kind: model.CouponFixedAmount, AmountOff: 1000, Currency: "USD"},
+		{Code: "SHIP", Kind: model.CouponFreeShipping},
+	})
+
+	assert.Len(t, cart.Discounts, 3)
+	assert.Equal(t, int64(1000), cart.DiscountTotal)
+	assert.Zero(t, cart.Total)
+	assert.True(t, cart.FreeShipping)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"time"
+)
+
+// IdempotencyStorage defines the interface for storing requests and responses by idempotency key.
+type IdempotencyStorage interface {
+	Reserve(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*model.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error
+	Release(ctx context.Context, scope, key string) error
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdempotencyService makes retried requests with the same idempotency key return the original response.
+type IdempotencyService struct {
+	repo  IdempotencyStorage
+	ttl   time.Duration
+	lease time.Duration
+	now   func() time.Time
+}
+
+// NewIdempotencyService creates a new instance of IdempotencyService.
+// Keys are remembered for ttl, after which they can be used again.
+// A request that has not completed within lease, e.g. because the process crashed, loses its key to a retry.
+func NewIdempotencyService(repo IdempotencyStorage, ttl, lease time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl, lease: lease, now: time.Now}
+}
+
+// Begin reserves the key of the caller scope for a request with the given hash.
+// It returns nil if the request is new and must be processed, followed by Complete or Release,
+// or the recorded response if the request is a replay.
+// Reusing the key for a different request and retrying while the original request is in progress are errors.
+func (s *IdempotencyService) Begin(ctx context.Context, scope, key, requestHash string) (*model.RecordedResponse, error) {
+	now := s.now()
+	record, reserved, err := s.repo.Reserve(ctx, scope, key, requestHash, now.Add(s.lease), now.Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+	if record.RequestHash != requestHash {
+		return nil, carterror.ErrIdempotencyKeyReused
+	}
+	if record.Response == nil {
+		return nil, carterror.ErrIdempotencyInProgress
+	}
+	return record.Response, nil
+}
+
+// Complete records the response of a request reserved with Begin.
+func (s *IdempotencyService) Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error {
+	return s.repo.Complete(ctx, scope, key, response)
+}
+
+// Release frees the key of a request reserved with Begin that failed, so that it can be retried.
+func (s *IdempotencyService) Release(ctx context.Context, scope, key string) error {
+	return s.repo.Release(ctx, scope, key)
+}
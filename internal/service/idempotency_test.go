@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockIdempotencyStorage struct {
+	record      *model.IdempotencyRecord
+	reserved    bool
+	reserveErr  error
+	lockedUntil time.Time
+	expiresAt   time.Time
+	completed   *model.RecordedResponse
+	releasedKey string
+}
+
+func (m *mockIdempotencyStorage) Reserve(ctx context.Context, scope, key, requestHash string, lockedUntil, expiresAt time.Time) (*model.IdempotencyRecord, bool, error) {
+	m.lockedUntil = lockedUntil
+	m.expiresAt = expiresAt
+	return m.record, m.reserved, m.reserveErr
+}
+
+func (m *mockIdempotencyStorage) Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error {
+	m.completed = &response
+	return nil
+}
+
+func (m *mockIdempotencyStorage) Release(ctx context.Context, scope, key string) error {
+	m.releasedKey = key
+	return nil
+}
+
+func (m *mockIdempotencyStorage) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestBegin_NewRequest(t *testing.T) {
+	mockRepo := &mockIdempotencyStorage{reserved: true}
+	service := service.NewIdempotencyService(mockRepo, time.Hour, time.Minute)
+
+	recorded, err := service.Begin(context.Background(), "user:user-1", "key-1", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, recorded)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), mockRepo.lockedUntil, time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), mockRepo.expiresAt, time.Minute)
+}
+
+func TestBegin_Replay(t *testing.T) {
+	response := &model.RecordedResponse{StatusCode: 201, Body: []byte(`{"id":"cart-id"}`)}
+	mockRepo := &mockIdempotencyStorage{record: &model.IdempotencyRecord{RequestHash: "hash", Response: response}}
+	service := service.NewIdempotencyService(mockRepo, time.Hour, time.Minute)
+
+	recorded, err := service.Begin(context.Background(), "user:user-1", "key-1", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, response, recorded)
+}
+
+func TestBegin_KeyReused(t *testing.T) {
+	response := &model.RecordedResponse{StatusCode: 201}
+	mockRepo := &mockIdempotencyStorage{record: &model.IdempotencyRecord{RequestHash: "other-hash", Response: response}}
+	service := service.NewIdempotencyService(mockRepo, time.Hour, time.Minute)
+
+	_, err := service.Begin(context.Background(), "user:user-1", "key-1", "hash")
+	assert.ErrorIs(t, err, carterror.ErrIdempotencyKeyReused)
+}
+
+func TestBegin_InProgress(t *testing.T) {
+	mockRepo := &mockIdempotencyStorage{record: &model.IdempotencyRecord{RequestHash: "hash"}}
+	service := service.NewIdempotencyService(mockRepo, time.Hour, time.Minute)
+
+	_, err := service.Begin(context.Background(), "user:user-1", "key-1", "hash")
+	assert.ErrorIs(t, err, carterror.ErrIdempotencyInProgress)
+}
+
+func TestBegin_StorageError(t *testing.T) {
+	mockRepo := &mockIdempotencyStorage{reserveErr: carterror.ErrFailedPostgresOpperation}
+	service := service.NewIdempotencyService(mockRepo, time.Hour, time.Minute)
+
+	_, err := service.Begin(context.Background(), "user:user-1", "key-1", "hash")
+	assert.ErrorIs(t, err, carterror.ErrFailedPostgresOpperation)
+}
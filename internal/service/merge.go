@@ -0,0 +1,46 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+)
+
+// MergeCarts moves the lines and coupons of the source cart into the target cart and deletes the source cart.
+// Quantities of products present in both carts are summed up to model.MaxItemQuantity.
+// Both carts must be active and their lines must share the same currency.
+// It returns the merged target cart, read within the same transaction as the merge.
+func (s *CartService) MergeCarts(ctx context.Context, targetID, sourceID string) (*model.Cart, error) {
+	ctx, span := tracer.Start(ctx, "CartService.MergeCarts")
+	defer span.End()
+	if sourceID == "" {
+		return nil, carterror.ErrSourceCartIDRequired
+	}
+	if targetID == sourceID {
+		return nil, carterror.ErrMergeIntoItself
+	}
+	// Both carts are locked in a stable order so that concurrent merges cannot deadlock.
+	first, second := targetID, sourceID
+	if second < first {
+		first, second = second, first
+	}
+	var cart *model.Cart
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := lockActive(ctx, s.repo, first); err != nil {
+			return err
+		}
+		if err := lockActive(ctx, s.repo, second); err != nil {
+			return err
+		}
+		if err := s.repo.Merge(ctx, targetID, sourceID, model.MaxItemQuantity); err != nil {
+			return err
+		}
+		var err error
+		cart, err = s.ViewCart(ctx, targetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeCarts_Success(t *testing.T) {
+	mockRepo := &mockCartStorage{
+		getCartResult: &model.Cart{ID: "target", Status: model.CartActive, Items: []model.CartItem{
+			{SKU: "product1", Quantity: 3, UnitPrice: 100, Currency: "USD"},
+		}},
+	}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	cart, err := service.MergeCarts(context.Background(), "target", "source")
+	assert.NoError(t, err)
+	assert.Equal(t, "source", mockRepo.mergedSource)
+	assert.Equal(t, 999, mockRepo.mergeMax)
+	assert.Equal(t, int64(300), cart.Subtotal)
+}
+
+func TestMergeCarts_IntoItself(t *testing.T) {
+	mockRepo := &mockCartStorage{}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	_, err := service.MergeCarts(context.Background(), "cart-id", "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrMergeIntoItself)
+	assert.Empty(t, mockRepo.mergedSource)
+}
+
+func TestMergeCarts_SourceNotActive(t *testing.T) {
+	mockRepo := &mockCartStorage{getCartResult: &model.Cart{ID: "source", Status: model.CartCheckedOut}}
+
+	service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+	_, err := service.MergeCarts(context.Background(), "target", "source")
+	assert.ErrorIs(t, err, carterror.ErrCartNotActive)
+	assert.True(t, mockRepo.locked)
+	assert.Empty(t, mockRepo.mergedSource)
+}
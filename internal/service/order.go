@@ -0,0 +1,91 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+)
+
+// OrderStorage defines the interface for interacting with order storage.
+type OrderStorage interface {
+	Create(ctx context.Context, order *model.Order) error
+}
+
+// OrderService provides business logic for turning carts into orders.
+type OrderService struct {
+	carts   CartStorage
+	coupons CouponStorage
+	orders  OrderStorage
+	tx      Transactor
+	pricer  *Pricer
+}
+
+// NewOrderService creates a new instance of OrderService.
+// It accepts CartStorage, CouponStorage, OrderStorage and Transactor implementations as dependencies.
+func NewOrderService(carts CartStorage, coupons CouponStorage, orders OrderStorage, tx Transactor) *OrderService {
+	return &OrderService{carts: carts, coupons: coupons, orders: orders, tx: tx, pricer: NewPricer()}
+}
+
+// Checkout prices the cart and snapshots its items, prices and applied discounts into an order.
+// Only active carts can be checked out; once checked out, the cart can no longer be changed.
+// The cart is locked while it is priced, so that the order matches the cart it was created from.
+func (s *OrderService) Checkout(ctx context.Context, cartID string) (*model.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrderService.Checkout")
+	defer span.End()
+	var order *model.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.carts.Lock(ctx, cartID); err != nil {
+			return err
+		}
+		cart, err := s.carts.Get(ctx, cartID)
+		if err != nil {
+			return err
+		}
+		if !canTransition(cart.Status, model.CartCheckedOut) {
+			return &carterror.StatusError{Status: string(cart.Status)}
+		}
+		if len(cart.Items) == 0 {
+			return carterror.ErrCartEmpty
+		}
+		coupons, err := s.coupons.ListForCart(ctx, cartID)
+		if err != nil {
+			return err
+		}
+		if err := s.pricer.Price(cart, coupons); err != nil {
+			return err
+		}
+
+		order = newOrder(cart)
+		if err := s.orders.Create(ctx, order); err != nil {
+			return err
+		}
+		return s.carts.SetStatus(ctx, cartID, cart.Status, model.CartCheckedOut)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// newOrder builds an order from a priced cart. Rejected coupons are not carried over.
+func newOrder(cart *model.Cart) *model.Order {
+	order := &model.Order{
+		CartID:        cart.ID,
+		Lines:         make([]model.OrderLine, 0, len(cart.Items)),
+		Currency:      cart.Currency,
+		Subtotal:      cart.Subtotal,
+		Discounts:     cart.Discounts,
+		DiscountTotal: cart.DiscountTotal,
+		Total:         cart.Total,
+		FreeShipping:  cart.FreeShipping,
+	}
+	for _, item := range cart.Items {
+		order.Lines = append(order.Lines, model.OrderLine{
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			LineTotal: item.LineTotal,
+		})
+	}
+	return order
+}
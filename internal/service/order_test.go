@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockOrderStorage struct {
+	created   *model.Order
+	createErr error
+}
+
+func (m *mockOrderStorage) Create(ctx context.Context, order *model.Order) error {
+	m.created = order
+	return m.createErr
+}
+
+func TestCheckout_Success(t *testing.T) {
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartActive, Items: []model.CartItem{
+		{SKU: "SHOES", Quantity: 2, UnitPrice: 500, Currency: "USD"},
+	}}}
+	coupons := &mockCouponStorage{attached: []model.Coupon{
+		{Code: "TEN", Kind: model.CouponPercentage, PercentOff: 10},
+		{Code: "BIG", Kind: model.CouponPercentage, PercentOff: 10, MinSubtotal: 5000},
+	}}
+	orders := &mockOrderStorage{}
+	tx := &mockTransactor{}
+
+	service := service.NewOrderService(carts, coupons, orders, tx)
+
+	order, err := service.Checkout(context.Background(), "cart-id")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, tx.calls)
+	assert.True(t, carts.locked)
+	assert.Same(t, orders.created, order)
+	assert.Equal(t, model.CartCheckedOut, carts.setStatus)
+	assert.Equal(t, "cart-id", order.CartID)
+	assert.Equal(t, []model.OrderLine{{SKU: "SHOES", Quantity: 2, UnitPrice: 500, LineTotal: 1000}}, order.Lines)
+	assert.Equal(t, int64(1000), order.Subtotal)
+	assert.Len(t, order.Discounts, 1)
+	assert.Equal(t, int64(900), order.Total)
+	assert.Equal(t, "USD", order.Currency)
+}
+
+func TestCheckout_AlreadyCheckedOut(t *testing.T) {
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartCheckedOut}}
+	orders := &mockOrderStorage{}
+
+	service := service.NewOrderService(carts, &mockCouponStorage{}, orders, &mockTransactor{})
+
+	order, err := service.Checkout(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartCheckedOut)
+	assert.EqualError(t, err, "cart is checked_out")
+	assert.Nil(t, order)
+	assert.Nil(t, orders.created)
+}
+
+func TestCheckout_EmptyCart(t *testing.T) {
+	carts := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: model.CartActive, Items: []model.CartItem{}}}
+	orders := &mockOrderStorage{}
+
+	service := service.NewOrderService(carts, &mockCouponStorage{}, orders, &mockTransactor{})
+
+	_, err := service.Checkout(context.Background(), "cart-id")
+	assert.ErrorIs(t, err, carterror.ErrCartEmpty)
+	assert.Nil(t, orders.created)
+}
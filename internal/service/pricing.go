@@ -0,0 +1,46 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+)
+
+// Pricer computes line totals and cart totals from the unit prices stored on the cart lines
+// and applies the discounts of the coupons attached to the cart.
+// All amounts are integer minor units; a cart can only be priced in a single currency.
+type Pricer struct {
+	discounts *DiscountEngine
+}
+
+// NewPricer creates a new instance of Pricer.
+func NewPricer() *Pricer {
+	return &Pricer{discounts: NewDiscountEngine()}
+}
+
+// Price fills in the line totals of every item, the subtotal, item count and currency of the cart,
+// and the discounts and total resulting from the given coupons.
+// It returns an error if the items are priced in different currencies.
+func (p *Pricer) Price(cart *model.Cart, coupons []model.Coupon) error {
+	cart.Subtotal = 0
+	cart.ItemCount = 0
+	cart.Currency = ""
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		priceLine(item)
+		if cart.Currency == "" {
+			cart.Currency = item.Currency
+		}
+		if item.Currency != cart.Currency {
+			return carterror.ErrCurrencyMismatch
+		}
+		cart.Subtotal += item.LineTotal
+		cart.ItemCount += item.Quantity
+	}
+	p.discounts.Apply(cart, coupons)
+	return nil
+}
+
+// priceLine computes the total of a single cart line.
+func priceLine(item *model.CartItem) {
+	item.LineTotal = item.UnitPrice * int64(item.Quantity)
+}
@@ -0,0 +1,65 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrice(t *testing.T) {
+	cart := &model.Cart{
+		ID: "cart-id",
+		Items: []model.CartItem{
+			{SKU: "A", Quantity: 2, UnitPrice: 150, Currency: "USD"},
+			{SKU: "B", Quantity: 3, UnitPrice: 999, Currency: "USD"},
+		},
+	}
+
+	err := service.NewPricer().Price(cart, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), cart.Items[0].LineTotal)
+	assert.Equal(t, int64(2997), cart.Items[1].LineTotal)
+	assert.Equal(t, int64(3297), cart.Subtotal)
+	assert.Equal(t, 5, cart.ItemCount)
+	assert.Equal(t, "USD", cart.Currency)
+	assert.Equal(t, int64(3297), cart.Total)
+}
+
+func TestPrice_EmptyCart(t *testing.T) {
+	cart := &model.Cart{ID: "cart-id", Items: []model.CartItem{}}
+
+	err := service.NewPricer().Price(cart, nil)
+	assert.NoError(t, err)
+	assert.Zero(t, cart.Subtotal)
+	assert.Zero(t, cart.ItemCount)
+	assert.Empty(t, cart.Currency)
+}
+
+func TestPrice_CurrencyMismatch(t *testing.T) {
+	cart := &model.Cart{
+		ID: "cart-id",
+		Items: []model.CartItem{
+			{SKU: "A", Quantity: 1, UnitPrice: 100, Currency: "USD"},
+			{SKU: "B", Quantity: 1, UnitPrice: 100, Currency: "EUR"},
+		},
+	}
+
+	err := service.NewPricer().Price(cart, nil)
+	assert.ErrorIs(t, err, carterror.ErrCurrencyMismatch)
+}
+
+func TestPrice_AppliesCoupons(t *testing.T) {
+	cart := &model.Cart{
+		ID:    "cart-id",
+		Items: []model.CartItem{{SKU: "A", Quantity: 4, UnitPrice: 250, Currency: "USD"}},
+	}
+
+	err := service.NewPricer().Price(cart, []model.Coupon{{Code: "TEN", Kind: model.CouponPercentage, PercentOff: 10}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), cart.Subtotal)
+	assert.Equal(t, int64(100), cart.DiscountTotal)
+	assert.Equal(t, int64(900), cart.Total)
+}
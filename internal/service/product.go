@@ -0,0 +1,90 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+	"strings"
+)
+
+// ProductStorage defines the interface for interacting with product storage.
+type ProductStorage interface {
+	Create(ctx context.Context, product *model.Product) error
+	Get(ctx context.Context, sku string) (*model.Product, error)
+	List(ctx context.Context) ([]model.Product, error)
+	Update(ctx context.Context, product *model.Product) error
+	Delete(ctx context.Context, sku string) error
+}
+
+// ProductService provides business logic for managing the product catalog.
+type ProductService struct {
+	repo ProductStorage
+}
+
+// NewProductService creates a new instance of ProductService.
+// It accepts a ProductStorage implementation as a dependency.
+func NewProductService(repo ProductStorage) *ProductService {
+	return &ProductService{repo: repo}
+}
+
+// CreateProduct validates and stores a new product.
+func (s *ProductService) CreateProduct(ctx context.Context, product *model.Product) error {
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, product)
+}
+
+// GetProduct retrieves a product by its SKU.
+func (s *ProductService) GetProduct(ctx context.Context, sku string) (*model.Product, error) {
+	return s.repo.Get(ctx, sku)
+}
+
+// ListProducts retrieves all products of the catalog.
+func (s *ProductService) ListProducts(ctx context.Context) ([]model.Product, error) {
+	return s.repo.List(ctx)
+}
+
+// UpdateProduct validates and overwrites an existing product.
+func (s *ProductService) UpdateProduct(ctx context.Context, product *model.Product) error {
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, product)
+}
+
+// DeleteProduct removes a product from the catalog.
+func (s *ProductService) DeleteProduct(ctx context.Context, sku string) error {
+	return s.repo.Delete(ctx, sku)
+}
+
+// validateProduct checks the product fields and normalizes the currency code to upper case.
+func validateProduct(product *model.Product) error {
+	if product.SKU == "" {
+		return carterror.ErrMissingSKU
+	}
+	if product.Name == "" {
+		return carterror.ErrMissingProductName
+	}
+	if product.UnitPrice < 0 {
+		return carterror.ErrPriceMustNotBeNegative
+	}
+	product.Currency = strings.ToUpper(product.Currency)
+	if !isCurrencyCode(product.Currency) {
+		return carterror.ErrInvalidCurrency
+	}
+	return nil
+}
+
+// isCurrencyCode reports whether code looks like an ISO 4217 alphabetic code.
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProductStorage struct {
+	created   *model.Product
+	createErr error
+	getResult *model.Product
+	getErr    error
+	updateErr error
+	deleteErr error
+}
+
+func (m *mockProductStorage) Create(ctx context.Context, product *model.Product) error {
+	m.created = product
+	return m.createErr
+}
+
+func (m *mockProductStorage) Get(ctx context.Context, sku string) (*model.Product, error) {
+	return m.getResult, m.getErr
+}
+
+func (m *mockProductStorage) List(ctx context.Context) ([]model.Product, error) {
+	return []model.Product{}, nil
+}
+
+func (m *mockProductStorage) Update(ctx context.Context, product *model.Product) error {
+	return m.updateErr
+}
+
+func (m *mockProductStorage) Delete(ctx context.Context, sku string) error {
+	return m.deleteErr
+}
+
+func TestCreateProduct_Success(t *testing.T) {
+	mockRepo := &mockProductStorage{}
+
+	service := service.NewProductService(mockRepo)
+
+	product := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 4999, Currency: "usd", Active: true}
+
+	err := service.CreateProduct(context.Background(), product)
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", mockRepo.created.Currency)
+}
+
+func TestCreateProduct_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		product model.Product
+		want    error
+	}{
+		{"missing sku", model.Product{Name: "Shoes", Currency: "USD"}, carterror.ErrMissingSKU},
+		{"missing name", model.Product{SKU: "SHOES-1", Currency: "USD"}, carterror.ErrMissingProductName},
+		{"negative price", model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: -1, Currency: "USD"}, carterror.ErrPriceMustNotBeNegative},
+		{"bad currency", model.Product{SKU: "SHOES-1", Name: "Shoes", Currency: "US"}, carterror.ErrInvalidCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockProductStorage{}
+
+			service := service.NewProductService(mockRepo)
+
+			err := service.CreateProduct(context.Background(), &tt.product)
+			assert.ErrorIs(t, err, tt.want)
+			assert.Nil(t, mockRepo.created)
+		})
+	}
+}
+
+func TestGetProduct_NotFound(t *testing.T) {
+	mockRepo := &mockProductStorage{getErr: carterror.ErrProductDoesNotExist}
+
+	service := service.NewProductService(mockRepo)
+
+	product, err := service.GetProduct(context.Background(), "SHOES-1")
+	assert.ErrorIs(t, err, carterror.ErrProductDoesNotExist)
+	assert.Nil(t, product)
+}
+
+func TestDeleteProduct_InUse(t *testing.T) {
+	mockRepo := &mockProductStorage{deleteErr: carterror.ErrProductInUse}
+
+	service := service.NewProductService(mockRepo)
+
+	err := service.DeleteProduct(context.Background(), "SHOES-1")
+	assert.ErrorIs(t, err, carterror.ErrProductInUse)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"cart-api/internal/model"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// CartReaperStorage defines the interface for expiring and purging stale carts.
+type CartReaperStorage interface {
+	ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error)
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// KeyPurgeStorage defines the interface for deleting expired idempotency keys.
+type KeyPurgeStorage interface {
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Reaper periodically expires carts whose lifetime has passed
+// and deletes expired carts once the retention period is over, along with expired idempotency keys.
+type Reaper struct {
+	repo      CartReaperStorage
+	keys      KeyPurgeStorage
+	interval  time.Duration
+	retention time.Duration
+	now       func() time.Time
+	logger    *slog.Logger
+}
+
+// NewReaper creates a new instance of Reaper that runs every interval
+// and keeps expired carts for the retention period, logging its work to logger.
+func NewReaper(repo CartReaperStorage, keys KeyPurgeStorage, interval, retention time.Duration, logger *slog.Logger) *Reaper {
+	return &Reaper{repo: repo, keys: keys, interval: interval, retention: retention, now: time.Now, logger: logger}
+}
+
+// Run reaps carts every interval until the context is cancelled.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Reap(ctx); err != nil {
+				r.logger.ErrorContext(ctx, "reap stale carts", "error", err)
+			}
+		}
+	}
+}
+
+// Reap expires the stale carts that may still move to the expired status
+// and purges the carts that have been expired for longer than the retention period
+// and the idempotency keys that have expired.
+func (r *Reaper) Reap(ctx context.Context) error {
+	now := r.now()
+	expired, err := r.repo.ExpireStale(ctx, now, expirableStatuses())
+	if err != nil {
+		return err
+	}
+	purged, err := r.repo.PurgeExpired(ctx, now.Add(-r.retention))
+	if err != nil {
+		return err
+	}
+	keys, err := r.keys.PurgeExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+	if expired > 0 || purged > 0 || keys > 0 {
+		r.logger.InfoContext(ctx, "reaped stale carts", "expired_carts", expired, "purged_carts", purged, "purged_keys", keys)
+	}
+	return nil
+}
+
+// expirableStatuses lists the statuses a cart may expire from according to the transition table.
+func expirableStatuses() []model.CartStatus {
+	var statuses []model.CartStatus
+	for from := range cartTransitions {
+		if canTransition(from, model.CartExpired) {
+			statuses = append(statuses, from)
+		}
+	}
+	return statuses
+}
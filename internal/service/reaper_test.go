@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReaperStorage struct {
+	expireFrom  []model.CartStatus
+	expireErr   error
+	purgeBefore time.Time
+	purged      bool
+}
+
+func (m *mockReaperStorage) ExpireStale(ctx context.Context, now time.Time, from []model.CartStatus) (int64, error) {
+	m.expireFrom = from
+	return int64(len(from)), m.expireErr
+}
+
+func (m *mockReaperStorage) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.purged = true
+	m.purgeBefore = before
+	return 0, nil
+}
+
+type mockKeyPurgeStorage struct {
+	purgeBefore time.Time
+}
+
+func (m *mockKeyPurgeStorage) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	m.purgeBefore = before
+	return 0, nil
+}
+
+func TestReap(t *testing.T) {
+	mockRepo := &mockReaperStorage{}
+	mockKeys := &mockKeyPurgeStorage{}
+	reaper := service.NewReaper(mockRepo, mockKeys, time.Minute, 24*time.Hour, logging.Discard())
+
+	err := reaper.Reap(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []model.CartStatus{model.CartActive, model.CartLocked, model.CartAbandoned}, mockRepo.expireFrom)
+	assert.True(t, mockRepo.purged)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), mockRepo.purgeBefore, time.Minute)
+	assert.WithinDuration(t, time.Now(), mockKeys.purgeBefore, time.Minute)
+}
+
+func TestReap_ExpireFails(t *testing.T) {
+	mockRepo := &mockReaperStorage{expireErr: carterror.ErrFailedPostgresOpperation}
+	reaper := service.NewReaper(mockRepo, &mockKeyPurgeStorage{}, time.Minute, 24*time.Hour, logging.Discard())
+
+	err := reaper.Reap(context.Background())
+	assert.ErrorIs(t, err, carterror.ErrFailedPostgresOpperation)
+	assert.False(t, mockRepo.purged)
+}
+
+func TestRun_StopsWhenContextIsCancelled(t *testing.T) {
+	reaper := service.NewReaper(&mockReaperStorage{}, &mockKeyPurgeStorage{}, time.Millisecond, time.Hour, logging.Discard())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		reaper.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not stop after the context was cancelled")
+	}
+}
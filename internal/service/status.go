@@ -0,0 +1,81 @@
+package service
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"context"
+)
+
+// cartTransitions lists, for every cart status, the statuses the cart may move to.
+// Checked out and expired carts are final.
+var cartTransitions = map[model.CartStatus][]model.CartStatus{
+	model.CartActive:     {model.CartLocked, model.CartCheckedOut, model.CartAbandoned, model.CartExpired},
+	model.CartLocked:     {model.CartActive, model.CartAbandoned, model.CartExpired},
+	model.CartAbandoned:  {model.CartActive, model.CartExpired},
+	model.CartCheckedOut: {},
+	model.CartExpired:    {},
+}
+
+// canTransition reports whether a cart may move from one status to another.
+func canTransition(from, to model.CartStatus) bool {
+	for _, allowed := range cartTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// lockActive locks the cart until the end of the unit of work running in ctx
+// and verifies that it is active, so that its items and coupons may still be changed.
+func lockActive(ctx context.Context, carts CartLocker, id string) error {
+	return lockMatching(ctx, carts, id, nil)
+}
+
+// lockMatching locks the active cart like lockActive and, unless ifMatch is nil, verifies that ifMatch
+// accepts its version. The version is checked under the lock, so the cart cannot change before the write.
+func lockMatching(ctx context.Context, carts CartLocker, id string, ifMatch func(version int64) bool) error {
+	cart, err := carts.Lock(ctx, id)
+	if err != nil {
+		return err
+	}
+	if cart.Status != model.CartActive {
+		return &carterror.StatusError{Status: string(cart.Status)}
+	}
+	if ifMatch != nil && !ifMatch(cart.Version) {
+		return &carterror.VersionError{Version: cart.Version}
+	}
+	return nil
+}
+
+// ChangeStatus moves the cart to another status according to the transition table
+// and returns the updated cart. Carts can only be checked out through checkout.
+func (s *CartService) ChangeStatus(ctx context.Context, id string, to model.CartStatus) (*model.Cart, error) {
+	ctx, span := tracer.Start(ctx, "CartService.ChangeStatus")
+	defer span.End()
+	if _, known := cartTransitions[to]; !known {
+		return nil, carterror.ErrInvalidStatus
+	}
+	if to == model.CartCheckedOut {
+		return nil, carterror.ErrInvalidStatusTransition
+	}
+	var cart *model.Cart
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		locked, err := s.repo.Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !canTransition(locked.Status, to) {
+			return carterror.ErrInvalidStatusTransition
+		}
+		if err := s.repo.SetStatus(ctx, id, locked.Status, to); err != nil {
+			return err
+		}
+		cart, err = s.ViewCart(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    model.CartStatus
+		to      model.CartStatus
+		wantErr error
+	}{
+		{"lock active cart", model.CartActive, model.CartLocked, nil},
+		{"unlock locked cart", model.CartLocked, model.CartActive, nil},
+		{"resume abandoned cart", model.CartAbandoned, model.CartActive, nil},
+		{"abandon active cart", model.CartActive, model.CartAbandoned, nil},
+		{"lock abandoned cart", model.CartAbandoned, model.CartLocked, carterror.ErrInvalidStatusTransition},
+		{"reopen checked out cart", model.CartCheckedOut, model.CartActive, carterror.ErrInvalidStatusTransition},
+		{"reopen expired cart", model.CartExpired, model.CartActive, carterror.ErrInvalidStatusTransition},
+		{"check out without checkout", model.CartActive, model.CartCheckedOut, carterror.ErrInvalidStatusTransition},
+		{"unknown status", model.CartActive, model.CartStatus("deleted"), carterror.ErrInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockCartStorage{getCartResult: &model.Cart{ID: "cart-id", Status: tt.from}}
+
+			service := service.NewCartService(mockRepo, &mockCouponStorage{}, &mockTransactor{}, time.Hour)
+
+			_, err := service.ChangeStatus(context.Background(), "cart-id", tt.to)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, mockRepo.setStatus)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.to, mockRepo.setStatus)
+		})
+	}
+}
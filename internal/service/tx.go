@@ -0,0 +1,10 @@
+package service
+
+import "context"
+
+// Transactor defines the interface for running a unit of work atomically.
+// Storage calls made with the context passed to fn take part in the same transaction,
+// which is committed if fn succeeds and rolled back otherwise.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,357 @@
+// Package storagetest provides the conformance suite that every storage backend must pass,
+// so that the backends can be used interchangeably.
+package storagetest
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/service"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// CartStorage is the cart storage of a backend, including the operations of the reaper.
+type CartStorage interface {
+	service.CartStorage
+	service.CartReaperStorage
+}
+
+// Backend is a set of repositories sharing the same empty storage.
+type Backend struct {
+	Carts    CartStorage
+	Items    service.CartItemStorage
+	Products service.ProductStorage
+}
+
+// maxQuantity is the largest quantity of a cart line in the tests.
+const maxQuantity = 10
+
+// unknownID is a well-formed ID that no cart or item has.
+const unknownID = "00000000-0000-4000-8000-000000000000"
+
+var owner = model.Owner{Kind: model.OwnerSession, ID: "session-hash"}
+
+// Run runs the conformance suite. newBackend is called for every test and must return a backend without data.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, b Backend)
+	}{
+		{"CreateAndGetCart", testCreateAndGetCart},
+		{"UnknownCart", testUnknownCart},
+		{"DeleteCart", testDeleteCart},
+		{"AddItem", testAddItem},
+		{"AddItemMergesSameSKU", testAddItemMergesSameSKU},
+		{"AddItemBeyondMaxQuantity", testAddItemBeyondMaxQuantity},
+		{"AddItemCurrencyMismatch", testAddItemCurrencyMismatch},
+		{"UpdateAndRemoveItem", testUpdateAndRemoveItem},
+		{"UnknownItem", testUnknownItem},
+		{"ClearCart", testClearCart},
+		{"SetStatus", testSetStatus},
+		{"SetStatusRecordsCheckout", testSetStatusRecordsCheckout},
+		{"ChangeKeepsLifetime", testChangeKeepsLifetime},
+		{"MergeCarts", testMergeCarts},
+		{"MergeCartsCurrencyMismatch", testMergeCartsCurrencyMismatch},
+		{"ExpireAndPurge", testExpireAndPurge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBackend(t)
+			for _, product := range []model.Product{
+				{SKU: "apple", Name: "Apple", UnitPrice: 100, Currency: "USD", Active: true},
+				{SKU: "pear", Name: "Pear", UnitPrice: 150, Currency: "USD", Active: true},
+				{SKU: "plum", Name: "Plum", UnitPrice: 200, Currency: "EUR", Active: true},
+			} {
+				require.NoError(t, b.Products.Create(context.Background(), &product))
+			}
+			tt.fn(t, b)
+		})
+	}
+}
+
+func createCart(t *testing.T, b Backend) *model.Cart {
+	t.Helper()
+	cart, err := b.Carts.Create(context.Background(), owner, time.Hour)
+	require.NoError(t, err)
+	return cart
+}
+
+func addItem(t *testing.T, b Backend, cartID, sku string, quantity int) *model.CartItem {
+	t.Helper()
+	product, err := b.Products.Get(context.Background(), sku)
+	require.NoError(t, err)
+	item := &model.CartItem{CartID: cartID, SKU: sku, Quantity: quantity, UnitPrice: product.UnitPrice, Currency: product.Currency}
+	_, err = b.Items.Create(context.Background(), item, maxQuantity)
+	require.NoError(t, err)
+	return item
+}
+
+func getCart(t *testing.T, b Backend, id string) *model.Cart {
+	t.Helper()
+	cart, err := b.Carts.Get(context.Background(), id)
+	require.NoError(t, err)
+	return cart
+}
+
+func quantities(cart *model.Cart) map[string]int {
+	q := map[string]int{}
+	for _, item := range cart.Items {
+		q[item.SKU] = item.Quantity
+	}
+	return q
+}
+
+func testCreateAndGetCart(t *testing.T, b Backend) {
+	ctx := context.Background()
+	created := createCart(t, b)
+	assert.Equal(t, model.CartActive, created.Status)
+	assert.Equal(t, int64(1), created.Version)
+	assert.Empty(t, created.Items)
+	assert.WithinDuration(t, created.CreatedAt.Add(time.Hour), created.ExpiresAt, time.Second)
+
+	cart := getCart(t, b, created.ID)
+	assert.Equal(t, created.ID, cart.ID)
+	assert.Equal(t, model.CartActive, cart.Status)
+	assert.Equal(t, int64(1), cart.Version)
+	assert.Empty(t, cart.Items)
+	assert.Empty(t, cart.Transitions)
+
+	got, err := b.Carts.GetOwner(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, owner, got)
+
+	locked, err := b.Carts.Lock(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.CartActive, locked.Status)
+	assert.Equal(t, int64(1), locked.Version)
+}
+
+func testUnknownCart(t *testing.T, b Backend) {
+	ctx := context.Background()
+	_, err := b.Carts.Get(ctx, unknownID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+	_, err = b.Carts.GetOwner(ctx, unknownID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+	_, err = b.Carts.Lock(ctx, unknownID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+	assert.ErrorIs(t, b.Carts.Delete(ctx, unknownID), carterror.ErrCartDoesNotExist)
+	assert.ErrorIs(t, b.Carts.Clear(ctx, unknownID), carterror.ErrCartDoesNotExist)
+	assert.ErrorIs(t, b.Carts.SetStatus(ctx, unknownID, model.CartActive, model.CartLocked), carterror.ErrCartDoesNotExist)
+
+	_, err = b.Items.Create(ctx, &model.CartItem{CartID: unknownID, SKU: "apple", Quantity: 1, UnitPrice: 100, Currency: "USD"}, maxQuantity)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
+
+func testDeleteCart(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	addItem(t, b, cart.ID, "apple", 1)
+
+	assert.NoError(t, b.Carts.Delete(context.Background(), cart.ID))
+	_, err := b.Carts.Get(context.Background(), cart.ID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
+
+func testAddItem(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	item := &model.CartItem{CartID: cart.ID, SKU: "apple", Quantity: 2, UnitPrice: 100, Currency: "USD"}
+
+	merged, err := b.Items.Create(context.Background(), item, maxQuantity)
+	assert.NoError(t, err)
+	assert.False(t, merged)
+	assert.NotEmpty(t, item.ID)
+
+	got := getCart(t, b, cart.ID)
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, item.ID, got.Items[0].ID)
+	assert.Equal(t, cart.ID, got.Items[0].CartID)
+	assert.Equal(t, 2, got.Items[0].Quantity)
+	assert.Equal(t, int64(100), got.Items[0].UnitPrice)
+	assert.Equal(t, "USD", got.Items[0].Currency)
+	assert.Equal(t, int64(2), got.Version)
+	assert.False(t, got.UpdatedAt.Before(cart.UpdatedAt))
+}
+
+func testAddItemMergesSameSKU(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	first := addItem(t, b, cart.ID, "apple", 2)
+
+	item := &model.CartItem{CartID: cart.ID, SKU: "apple", Quantity: 3, UnitPrice: 120, Currency: "USD"}
+	merged, err := b.Items.Create(context.Background(), item, maxQuantity)
+	assert.NoError(t, err)
+	assert.True(t, merged)
+	assert.Equal(t, first.ID, item.ID)
+	assert.Equal(t, 5, item.Quantity)
+
+	got := getCart(t, b, cart.ID)
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, 5, got.Items[0].Quantity)
+	assert.Equal(t, int64(120), got.Items[0].UnitPrice)
+}
+
+func testAddItemBeyondMaxQuantity(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	addItem(t, b, cart.ID, "apple", 6)
+
+	_, err := b.Items.Create(context.Background(), &model.CartItem{CartID: cart.ID, SKU: "apple", Quantity: 5, UnitPrice: 100, Currency: "USD"}, maxQuantity)
+	assert.ErrorIs(t, err, carterror.ErrQuantityTooLarge)
+
+	got := getCart(t, b, cart.ID)
+	require.Len(t, got.Items, 1)
+	assert.Equal(t, 6, got.Items[0].Quantity)
+}
+
+func testAddItemCurrencyMismatch(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	addItem(t, b, cart.ID, "apple", 1)
+
+	_, err := b.Items.Create(context.Background(), &model.CartItem{CartID: cart.ID, SKU: "plum", Quantity: 1, UnitPrice: 200, Currency: "EUR"}, maxQuantity)
+	assert.ErrorIs(t, err, carterror.ErrCurrencyMismatch)
+	assert.Len(t, getCart(t, b, cart.ID).Items, 1)
+}
+
+func testUpdateAndRemoveItem(t *testing.T, b Backend) {
+	ctx := context.Background()
+	cart := createCart(t, b)
+	apple := addItem(t, b, cart.ID, "apple", 1)
+	pear := addItem(t, b, cart.ID, "pear", 1)
+
+	updated, err := b.Items.UpdateQuantity(ctx, cart.ID, apple.ID, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, apple.ID, updated.ID)
+	assert.Equal(t, "apple", updated.SKU)
+	assert.Equal(t, 7, updated.Quantity)
+
+	assert.NoError(t, b.Items.Delete(ctx, cart.ID, pear.ID))
+
+	got := getCart(t, b, cart.ID)
+	assert.Equal(t, map[string]int{"apple": 7}, quantities(got))
+	assert.Equal(t, int64(5), got.Version)
+}
+
+func testUnknownItem(t *testing.T, b Backend) {
+	ctx := context.Background()
+	cart := createCart(t, b)
+	other := createCart(t, b)
+	item := addItem(t, b, other.ID, "apple", 1)
+
+	_, err := b.Items.UpdateQuantity(ctx, cart.ID, unknownID, 2)
+	assert.ErrorIs(t, err, carterror.ErrCartItemDoesNotExist)
+	assert.ErrorIs(t, b.Items.Delete(ctx, cart.ID, unknownID), carterror.ErrCartItemDoesNotExist)
+
+	// Items are only found within their own cart.
+	_, err = b.Items.UpdateQuantity(ctx, cart.ID, item.ID, 2)
+	assert.ErrorIs(t, err, carterror.ErrCartItemDoesNotExist)
+	assert.ErrorIs(t, b.Items.Delete(ctx, cart.ID, item.ID), carterror.ErrCartItemDoesNotExist)
+}
+
+func testClearCart(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	addItem(t, b, cart.ID, "apple", 1)
+	addItem(t, b, cart.ID, "pear", 1)
+
+	assert.NoError(t, b.Carts.Clear(context.Background(), cart.ID))
+	got := getCart(t, b, cart.ID)
+	assert.Empty(t, got.Items)
+	assert.Equal(t, int64(4), got.Version)
+}
+
+func testSetStatus(t *testing.T, b Backend) {
+	ctx := context.Background()
+	cart := createCart(t, b)
+
+	assert.NoError(t, b.Carts.SetStatus(ctx, cart.ID, model.CartActive, model.CartLocked))
+	assert.ErrorIs(t, b.Carts.SetStatus(ctx, cart.ID, model.CartActive, model.CartAbandoned), carterror.ErrInvalidStatusTransition)
+	assert.NoError(t, b.Carts.SetStatus(ctx, cart.ID, model.CartLocked, model.CartActive))
+
+	got := getCart(t, b, cart.ID)
+	assert.Equal(t, model.CartActive, got.Status)
+	assert.Equal(t, int64(3), got.Version)
+	require.Len(t, got.Transitions, 2)
+	assert.Equal(t, model.CartActive, got.Transitions[0].From)
+	assert.Equal(t, model.CartLocked, got.Transitions[0].To)
+	assert.Equal(t, model.CartLocked, got.Transitions[1].From)
+	assert.Equal(t, model.CartActive, got.Transitions[1].To)
+}
+
+func testSetStatusRecordsCheckout(t *testing.T, b Backend) {
+	cart := createCart(t, b)
+	assert.Nil(t, getCart(t, b, cart.ID).CheckedOutAt)
+
+	require.NoError(t, b.Carts.SetStatus(context.Background(), cart.ID, model.CartActive, model.CartCheckedOut))
+	got := getCart(t, b, cart.ID)
+	assert.Equal(t, model.CartCheckedOut, got.Status)
+	require.NotNil(t, got.CheckedOutAt)
+	require.Len(t, got.Transitions, 1)
+	assert.True(t, got.CheckedOutAt.Equal(got.Transitions[0].At))
+}
+
+func testChangeKeepsLifetime(t *testing.T, b Backend) {
+	ctx := context.Background()
+	cart := createCart(t, b)
+	require.NoError(t, b.Carts.SetStatus(ctx, cart.ID, model.CartActive, model.CartLocked))
+	require.NoError(t, b.Carts.SetStatus(ctx, cart.ID, model.CartLocked, model.CartActive))
+
+	addItem(t, b, cart.ID, "apple", 1)
+	got := getCart(t, b, cart.ID)
+	assert.Equal(t, time.Hour, got.ExpiresAt.Sub(got.UpdatedAt))
+}
+
+func testMergeCarts(t *testing.T, b Backend) {
+	ctx := context.Background()
+	target := createCart(t, b)
+	source := createCart(t, b)
+	addItem(t, b, target.ID, "apple", 8)
+	addItem(t, b, source.ID, "apple", 5)
+	addItem(t, b, source.ID, "pear", 12)
+
+	assert.NoError(t, b.Carts.Merge(ctx, target.ID, source.ID, 10))
+
+	got := getCart(t, b, target.ID)
+	assert.Equal(t, map[string]int{"apple": 10, "pear": 10}, quantities(got))
+	_, err := b.Carts.Get(ctx, source.ID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
+
+func testMergeCartsCurrencyMismatch(t *testing.T, b Backend) {
+	ctx := context.Background()
+	target := createCart(t, b)
+	source := createCart(t, b)
+	addItem(t, b, target.ID, "apple", 1)
+	addItem(t, b, source.ID, "plum", 1)
+
+	assert.ErrorIs(t, b.Carts.Merge(ctx, target.ID, source.ID, 10), carterror.ErrCurrencyMismatch)
+	assert.Len(t, getCart(t, b, source.ID).Items, 1)
+	assert.Len(t, getCart(t, b, target.ID).Items, 1)
+}
+
+func testExpireAndPurge(t *testing.T, b Backend) {
+	ctx := context.Background()
+	stale := createCart(t, b)
+	locked := createCart(t, b)
+	require.NoError(t, b.Carts.SetStatus(ctx, locked.ID, model.CartActive, model.CartLocked))
+
+	now := time.Now().Add(2 * time.Hour)
+	expired, err := b.Carts.ExpireStale(ctx, now, []model.CartStatus{model.CartActive})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+
+	got := getCart(t, b, stale.ID)
+	assert.Equal(t, model.CartExpired, got.Status)
+	require.Len(t, got.Transitions, 1)
+	assert.Equal(t, model.CartExpired, got.Transitions[0].To)
+	assert.Equal(t, model.CartLocked, getCart(t, b, locked.ID).Status)
+
+	purged, err := b.Carts.PurgeExpired(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = b.Carts.PurgeExpired(ctx, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = b.Carts.Get(ctx, stale.ID)
+	assert.ErrorIs(t, err, carterror.ErrCartDoesNotExist)
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Queryer is the part of sqlx.DB and sqlx.Tx used by the SQL repositories.
+type Queryer interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// SQL returns a Queryer that runs every query of q within a client span.
+// system is the database system the spans are labeled with, e.g. "postgresql".
+// The spans of QueryContext and QueryxContext end when the rows are returned, not when they are read.
+func SQL(q Queryer, system string) Queryer {
+	return &tracedQueryer{Queryer: q, system: semconv.DBSystemKey.String(system)}
+}
+
+type tracedQueryer struct {
+	Queryer
+	system attribute.KeyValue
+}
+
+func (q *tracedQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := q.start(ctx, query)
+	rows, err := q.Queryer.QueryContext(ctx, query, args...)
+	end(span, err)
+	return rows, err
+}
+
+func (q *tracedQueryer) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	ctx, span := q.start(ctx, query)
+	rows, err := q.Queryer.QueryxContext(ctx, query, args...)
+	end(span, err)
+	return rows, err
+}
+
+func (q *tracedQueryer) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	ctx, span := q.start(ctx, query)
+	row := q.Queryer.QueryRowxContext(ctx, query, args...)
+	end(span, row.Err())
+	return row
+}
+
+func (q *tracedQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := q.start(ctx, query)
+	res, err := q.Queryer.ExecContext(ctx, query, args...)
+	end(span, err)
+	return res, err
+}
+
+func (q *tracedQueryer) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, span := q.start(ctx, query)
+	err := q.Queryer.GetContext(ctx, dest, query, args...)
+	end(span, err)
+	return err
+}
+
+func (q *tracedQueryer) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, span := q.start(ctx, query)
+	err := q.Queryer.SelectContext(ctx, dest, query, args...)
+	end(span, err)
+	return err
+}
+
+// start starts the span of query, named after its leading keyword, e.g. SELECT.
+func (q *tracedQueryer) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := query
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	return tracer.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(q.system, semconv.DBOperationName(operation), semconv.DBQueryText(query)),
+	)
+}
+
+// end ends span, marking it as failed if err is set. A query without rows is not a failure.
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
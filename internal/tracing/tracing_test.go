@@ -0,0 +1,129 @@
+package tracing_test
+
+import (
+	"cart-api/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// exporter collects the spans of all tests. The global tracer provider can only be installed once
+// for the package tracer, so the tests share it and reset the exporter instead.
+var exporter = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	os.Exit(m.Run())
+}
+
+// spans returns the spans ended since the last call.
+func spans(t *testing.T) tracetest.SpanStubs {
+	t.Helper()
+	got := exporter.GetSpans()
+	exporter.Reset()
+	return got
+}
+
+func attr(s tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestRoute(t *testing.T) {
+	exporter.Reset()
+	var inner trace.SpanContext
+	h := tracing.Route("GET /carts/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/carts/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	got := spans(t)
+	require.Len(t, got, 1)
+	span := got[0]
+	assert.Equal(t, "GET /carts/{id}", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+	assert.Equal(t, span.SpanContext.SpanID(), inner.SpanID())
+	assert.Equal(t, int64(http.StatusNotFound), attr(span, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Unset, span.Status.Code)
+}
+
+func TestRoute_ServerError(t *testing.T) {
+	exporter.Reset()
+	h := tracing.Route("POST /carts", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/carts", nil))
+
+	got := spans(t)
+	require.Len(t, got, 1)
+	assert.False(t, got[0].Parent.IsValid())
+	assert.Equal(t, codes.Error, got[0].Status.Code)
+}
+
+func TestSQL(t *testing.T) {
+	exporter.Reset()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	q := tracing.SQL(sqlx.NewDb(db, "sqlmock"), "postgresql")
+
+	mock.ExpectExec("DELETE FROM carts").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("(?i)SELECT version FROM carts").WithArgs("2").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery("SELECT version FROM carts").WithArgs("3").WillReturnError(errors.New("connection reset"))
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	_, err = q.ExecContext(ctx, "DELETE FROM carts WHERE id = $1", "1")
+	require.NoError(t, err)
+	var version int64
+	assert.ErrorIs(t, q.GetContext(ctx, &version, "  select version FROM carts WHERE id = $1", "2"), sql.ErrNoRows)
+	assert.Error(t, q.GetContext(ctx, &version, "SELECT version FROM carts WHERE id = $1", "3"))
+	parent.End()
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	got := spans(t)
+	require.Len(t, got, 4)
+	for i, want := range []struct {
+		name   string
+		status codes.Code
+	}{
+		{"DELETE", codes.Unset},
+		{"SELECT", codes.Unset},
+		{"SELECT", codes.Error},
+	} {
+		span := got[i]
+		assert.Equal(t, want.name, span.Name)
+		assert.Equal(t, want.status, span.Status.Code)
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind)
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent.SpanID())
+		assert.Equal(t, "postgresql", attr(span, "db.system").AsString())
+		assert.Equal(t, want.name, attr(span, "db.operation.name").AsString())
+	}
+}
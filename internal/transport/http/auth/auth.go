@@ -0,0 +1,192 @@
+// Package auth authenticates HTTP requests with JWT bearer tokens.
+package auth
+
+import (
+	"cart-api/internal/config"
+	"cart-api/internal/transport/http/problem"
+	"context"
+	"crypto/rsa"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ScopeCatalogWrite is the scope a token needs to change the product catalog.
+const ScopeCatalogWrite = "catalog:write"
+
+var (
+	errMissingToken       = errors.New("missing bearer token")
+	errMissingCredentials = errors.New("missing bearer token or session token")
+	errInvalidToken       = errors.New("invalid bearer token")
+	errInsufficientScope  = errors.New("bearer token lacks the required scope")
+)
+
+type subjectKey struct{}
+
+type scopesKey struct{}
+
+// WithSubject returns a copy of ctx carrying the subject of an authenticated caller.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Subject returns the subject of the authenticated caller, if the request was authenticated.
+func Subject(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectKey{}).(string)
+	return subject, ok && subject != ""
+}
+
+// WithScopes returns a copy of ctx carrying the scopes granted to an authenticated caller.
+func WithScopes(ctx context.Context, scopes ...string) context.Context {
+	return context.WithValue(ctx, scopesKey{}, scopes)
+}
+
+// HasScope reports whether the authenticated caller was granted scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, _ := ctx.Value(scopesKey{}).([]string)
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticator validates HS256 and RS256 signed JWTs.
+type Authenticator struct {
+	keys   *keySet
+	parser *jwt.Parser
+	logger *slog.Logger
+}
+
+// New creates an Authenticator from the keys, issuer and audience in cfg.
+// Tokens are verified with the HMAC secret, the RSA public key file and the keys of the local JWKS file, whichever are configured.
+// Without any key every token is rejected. Rejected tokens are logged to logger.
+func New(cfg config.Config, logger *slog.Logger) (*Authenticator, error) {
+	keys := &keySet{
+		secret:  []byte(cfg.JWTSecret),
+		secrets: map[string][]byte{},
+		rsaKeys: map[string]*rsa.PublicKey{},
+	}
+	if cfg.JWTPublicKeyFile != "" {
+		key, err := loadPublicKey(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys.publicKey = key
+	}
+	if cfg.JWTJWKSFile != "" {
+		if err := keys.loadJWKS(cfg.JWTJWKSFile); err != nil {
+			return nil, err
+		}
+	}
+	if keys.empty() {
+		logger.Warn("no JWT keys are configured, every bearer token will be rejected")
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWTAudience))
+	}
+	return &Authenticator{keys: keys, parser: jwt.NewParser(options...), logger: logger}, nil
+}
+
+// Authenticate validates the bearer token of the request, if there is one,
+// and puts its subject and the scopes of its space-separated scope claim into the request context.
+// Requests without a token pass through anonymously; requests with an invalid token get 401.
+func (a *Authenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		subject, scopes, err := a.verify(header)
+		if err != nil {
+			a.logger.InfoContext(r.Context(), "authentication failed", "error", err)
+			writeUnauthorized(w, r, "invalid_token", errInvalidToken)
+			return
+		}
+		ctx := WithScopes(WithSubject(r.Context(), subject), scopes...)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Require rejects requests that were not authenticated with 401.
+// It must run after Authenticate.
+func Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := Subject(r.Context()); !ok {
+			writeUnauthorized(w, r, "missing_token", errMissingToken)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireCaller rejects requests with 401 that were not authenticated
+// and do not carry a credential of their own in header either, such as the token of an anonymous session.
+// It must run after Authenticate.
+func RequireCaller(header string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := Subject(r.Context()); !ok && r.Header.Get(header) == "" {
+			writeUnauthorized(w, r, "missing_token", errMissingCredentials)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireScope rejects requests that were not authenticated with 401
+// and requests whose token was not granted scope with 403.
+// It must run after Authenticate.
+func RequireScope(scope string, next http.Handler) http.Handler {
+	return Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !HasScope(r.Context(), scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cart-api", error="insufficient_scope", scope="`+scope+`"`)
+			problem.Write(w, r, http.StatusForbidden, "insufficient_scope", errInsufficientScope.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// verify parses the bearer token of an Authorization header and returns its subject and scopes.
+func (a *Authenticator) verify(header string) (string, []string, error) {
+	scheme, raw, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || raw == "" {
+		return "", nil, errors.New("authorization header is not a bearer token")
+	}
+	token, err := a.parser.Parse(raw, a.keys.keyFor)
+	if err != nil {
+		return "", nil, err
+	}
+	subject, err := token.Claims.GetSubject()
+	if err != nil {
+		return "", nil, err
+	}
+	if subject == "" {
+		return "", nil, errors.New("token has no subject")
+	}
+	var scopes []string
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		scope, _ := claims["scope"].(string)
+		scopes = strings.Fields(scope)
+	}
+	return subject, scopes, nil
+}
+
+// writeUnauthorized writes a 401 response with problem details.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, code string, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="cart-api"`)
+	problem.Write(w, r, http.StatusUnauthorized, code, err.Error())
+}
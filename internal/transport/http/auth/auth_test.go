@@ -0,0 +1,237 @@
+package auth_test
+
+import (
+	"cart-api/internal/config"
+	"cart-api/internal/logging"
+	"cart-api/internal/transport/http/auth"
+	"cart-api/internal/transport/http/problem"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "test-secret"
+
+func claims(subject string, expiresIn time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{"sub": subject, "exp": time.Now().Add(expiresIn).Unix()}
+}
+
+func signHS256(t *testing.T, key string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+// serve runs the request through Authenticate and returns the response
+// and the subject seen by the wrapped handler.
+func serve(t *testing.T, a *auth.Authenticator, token string) (*http.Response, string) {
+	var subject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, _ = auth.Subject(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/carts/123", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+
+	a.Authenticate(next).ServeHTTP(w, r)
+	return w.Result(), subject
+}
+
+func TestAuthenticate_HS256(t *testing.T) {
+	a, err := auth.New(config.Config{JWTSecret: secret}, logging.Discard())
+	assert.NoError(t, err)
+
+	res, subject := serve(t, a, signHS256(t, secret, claims("user-1", time.Hour)))
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "user-1", subject)
+}
+
+func TestAuthenticate_WithoutToken(t *testing.T) {
+	a, err := auth.New(config.Config{JWTSecret: secret}, logging.Discard())
+	assert.NoError(t, err)
+
+	res, subject := serve(t, a, "")
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, subject)
+}
+
+func TestAuthenticate_InvalidToken(t *testing.T) {
+	a, err := auth.New(config.Config{JWTSecret: secret}, logging.Discard())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong secret", signHS256(t, "other-secret", claims("user-1", time.Hour))},
+		{"expired", signHS256(t, secret, claims("user-1", -time.Hour))},
+		{"without expiry", signHS256(t, secret, jwt.MapClaims{"sub": "user-1"})},
+		{"without subject", signHS256(t, secret, jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()})},
+		{"malformed", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, subject := serve(t, a, tt.token)
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+			assert.Equal(t, problem.ContentType, res.Header.Get("Content-Type"))
+			assert.Empty(t, subject)
+
+			var body problem.Details
+			if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			assert.Equal(t, "invalid_token", body.Code)
+		})
+	}
+}
+
+func TestAuthenticate_RS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("failed to encode JWKS: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+
+	a, err := auth.New(config.Config{JWTJWKSFile: path}, logging.Discard())
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims("user-2", time.Hour))
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	res, subject := serve(t, a, signed)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "user-2", subject)
+
+	// A HS256 token must not be accepted when only RSA keys are configured.
+	res, _ = serve(t, a, signHS256(t, secret, claims("user-2", time.Hour)))
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestRequire(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/products", nil)
+	w := httptest.NewRecorder()
+	auth.Require(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = r.WithContext(auth.WithSubject(r.Context(), "admin"))
+	w = httptest.NewRecorder()
+	auth.Require(next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAuthenticate_Scopes(t *testing.T) {
+	a, err := auth.New(config.Config{JWTSecret: secret}, logging.Discard())
+	assert.NoError(t, err)
+
+	var granted, other bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		granted = auth.HasScope(r.Context(), auth.ScopeCatalogWrite)
+		other = auth.HasScope(r.Context(), "orders:read")
+	})
+	c := claims("admin", time.Hour)
+	c["scope"] = "profile " + auth.ScopeCatalogWrite
+
+	r := httptest.NewRequest(http.MethodPost, "/products", nil)
+	r.Header.Set("Authorization", "Bearer "+signHS256(t, secret, c))
+	w := httptest.NewRecorder()
+	a.Authenticate(next).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, granted)
+	assert.False(t, other)
+}
+
+func TestRequireScope(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/products", nil)
+	w := httptest.NewRecorder()
+	auth.RequireScope(auth.ScopeCatalogWrite, next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = r.WithContext(auth.WithSubject(r.Context(), "user-1"))
+	w = httptest.NewRecorder()
+	auth.RequireScope(auth.ScopeCatalogWrite, next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var body problem.Details
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, "insufficient_scope", body.Code)
+
+	r = r.WithContext(auth.WithScopes(r.Context(), auth.ScopeCatalogWrite))
+	w = httptest.NewRecorder()
+	auth.RequireScope(auth.ScopeCatalogWrite, next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestRequireCaller(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/carts/123", nil)
+	w := httptest.NewRecorder()
+	auth.RequireCaller("X-Session-Token", next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r.Header.Set("X-Session-Token", "session")
+	w = httptest.NewRecorder()
+	auth.RequireCaller("X-Session-Token", next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/carts/123", nil)
+	r = r.WithContext(auth.WithSubject(r.Context(), "user-1"))
+	w = httptest.NewRecorder()
+	auth.RequireCaller("X-Session-Token", next).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
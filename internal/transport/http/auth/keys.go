@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keySet holds the keys tokens may be signed with.
+// Keys from a JWKS file are looked up by the kid header of the token;
+// the configured secret and public key are used for tokens without a kid.
+type keySet struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	secrets   map[string][]byte
+	rsaKeys   map[string]*rsa.PublicKey
+}
+
+// empty reports whether no key is configured at all.
+func (k *keySet) empty() bool {
+	return len(k.secret) == 0 && k.publicKey == nil && len(k.secrets) == 0 && len(k.rsaKeys) == 0
+}
+
+// keyFor returns the key that verifies the token, depending on its signing method and kid.
+func (k *keySet) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if kid != "" {
+			if secret, ok := k.secrets[kid]; ok {
+				return secret, nil
+			}
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if len(k.secret) == 0 {
+			return nil, errors.New("no HS256 key configured")
+		}
+		return k.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		if kid != "" {
+			if key, ok := k.rsaKeys[kid]; ok {
+				return key, nil
+			}
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if k.publicKey == nil {
+			return nil, errors.New("no RS256 key configured")
+		}
+		return k.publicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// loadPublicKey reads a PEM encoded RSA public key from a file.
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return key, nil
+}
+
+// jwk is a single key of a JSON Web Key Set as described in RFC 7517.
+// Only RSA ("RSA") and symmetric ("oct") keys are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// loadJWKS reads the RSA and symmetric keys of a local JWKS file into the key set.
+func (k *keySet) loadJWKS(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	for _, key := range set.Keys {
+		if key.Kid == "" {
+			return errors.New("JWKS key without kid")
+		}
+		switch key.Kty {
+		case "RSA":
+			publicKey, err := rsaKey(key)
+			if err != nil {
+				return fmt.Errorf("JWKS key %q: %w", key.Kid, err)
+			}
+			k.rsaKeys[key.Kid] = publicKey
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(key.K)
+			if err != nil {
+				return fmt.Errorf("JWKS key %q: %w", key.Kid, err)
+			}
+			k.secrets[key.Kid] = secret
+		default:
+			return fmt.Errorf("JWKS key %q: unsupported key type %q", key.Kid, key.Kty)
+		}
+	}
+	return nil
+}
+
+// rsaKey builds an RSA public key from the base64url encoded modulus and exponent of a JWK.
+func rsaKey(key jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent is too large")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
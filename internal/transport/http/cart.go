@@ -3,147 +3,501 @@ package handler
 import (
 	"cart-api/internal/carterror"
 	"cart-api/internal/model"
+	"cart-api/internal/validation"
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 )
 
 // CartService defines the interface for cart-related operations.
 type CartService interface {
-	CreateCart(ctx context.Context) (*model.Cart, error)
+	CartOwnership
+	CreateCart(ctx context.Context, owner model.Owner) (*model.Cart, error)
 	ViewCart(ctx context.Context, cartID string) (*model.Cart, error)
+	DeleteCart(ctx context.Context, cartID string) error
+	ClearCart(ctx context.Context, cartID string) error
+	ApplyCoupon(ctx context.Context, cartID, code string) (*model.Cart, error)
+	RemoveCoupon(ctx context.Context, cartID, code string) error
+	ChangeStatus(ctx context.Context, cartID string, status model.CartStatus) (*model.Cart, error)
+	MergeCarts(ctx context.Context, targetID, sourceID string) (*model.Cart, error)
 }
 
 // CartItemService defines the interface for cart item-related operations.
 type CartItemService interface {
-	AddToCart(ctx context.Context, item *model.CartItem) error
-	RemoveFromCart(ctx context.Context, cartID, itemID string) error
+	AddToCart(ctx context.Context, item *model.CartItem, ifMatch func(version int64) bool) (bool, error)
+	RemoveFromCart(ctx context.Context, cartID, itemID string, ifMatch func(version int64) bool) error
+	UpdateQuantity(ctx context.Context, cartID, itemID string, quantity int, ifMatch func(version int64) bool) (*model.CartItem, error)
 }
 
 // CartHandler provides HTTP handlers for cart and cart item operations.
 type CartHandler struct {
 	cartService     CartService
 	cartItemService CartItemService
+	logger          *slog.Logger
 }
 
-// NewCartHandler creates a new instance of CartHandler.
-func NewCartHandler(c CartService, ci CartItemService) *CartHandler {
-	return &CartHandler{cartService: c, cartItemService: ci}
+// NewCartHandler creates a new instance of CartHandler that logs to logger.
+func NewCartHandler(c CartService, ci CartItemService, logger *slog.Logger) *CartHandler {
+	return &CartHandler{cartService: c, cartItemService: ci, logger: logger}
 }
 
-// CreateCart handles the creation of a new cart.
+// CreateCart handles the creation of a new cart owned by the caller.
+// Callers without an identity get a new anonymous session, whose token is returned in the SessionTokenHeader.
 func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
-	log.Println("CreateCart is called")
+	r, span := startSpan(r, "CartHandler.CreateCart")
+	defer span.End()
 	if r.Method != http.MethodPost {
-		http.Error(w, carterror.ErrInvalidRequestMethod.Error(), http.StatusMethodNotAllowed)
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
 		return
 	}
-	cart, err := h.cartService.CreateCart(r.Context())
+
+	owner := requestOwner(r)
+	if owner.ID == "" {
+		token, err := newSessionToken()
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		owner = sessionOwner(token)
+		w.Header().Set(SessionTokenHeader, token)
+	}
+
+	cart, err := h.cartService.CreateCart(r.Context(), owner)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(cart); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 }
 
 // ViewCart handles the retrieval of a cart by its ID.
+// The response carries the version of the cart as its ETag; a request whose If-None-Match
+// still matches the current version gets 304 Not Modified without a body.
 func (h *CartHandler) ViewCart(w http.ResponseWriter, r *http.Request) {
-	log.Println("ViewCart is called")
+	r, span := startSpan(r, "CartHandler.ViewCart")
+	defer span.End()
 	if r.Method != http.MethodGet {
-		http.Error(w, carterror.ErrInvalidRequestMethod.Error(), http.StatusMethodNotAllowed)
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
 		return
 	}
 	cartID := r.URL.Path[len("/carts/"):]
-	log.Println("Extracted id: ", cartID)
+	h.logger.DebugContext(r.Context(), "view cart", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
 
 	cart, err := h.cartService.ViewCart(r.Context(), cartID)
 	if err != nil {
-		http.Error(w, carterror.ErrCartDoesNotExist.Error(), http.StatusNotFound)
+		writeError(w, r, err)
+		return
+	}
+
+	etag := cartETag(cart.Version)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(cart); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 }
 
-// AddToCart handles the addition of an item to the cart.
-func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
-	log.Println("AddToCart is called")
+// DeleteCart handles the deletion of a cart together with all of its items.
+func (h *CartHandler) DeleteCart(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.DeleteCart")
+	defer span.End()
+	if r.Method != http.MethodDelete {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	cartID := r.URL.Path[len("/carts/"):]
+	h.logger.DebugContext(r.Context(), "delete cart", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+
+	err := h.cartService.DeleteCart(r.Context(), cartID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClearCart handles the removal of all items from the cart.
+func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.ClearCart")
+	defer span.End()
+	if r.Method != http.MethodDelete {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	cartID := strings.Split(r.URL.Path, "/")[2]
+	h.logger.DebugContext(r.Context(), "clear cart", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+
+	err := h.cartService.ClearCart(r.Context(), cartID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeStatus handles moving the cart to another lifecycle status.
+func (h *CartHandler) ChangeStatus(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.ChangeStatus")
+	defer span.End()
+	if r.Method != http.MethodPut {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	cartID := strings.Split(r.URL.Path, "/")[2]
+	h.logger.DebugContext(r.Context(), "change cart status", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+
+	var request struct {
+		Status model.CartStatus `json:"status"`
+	}
+
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	v.Required("status", string(request.Status))
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+
+	cart, err := h.cartService.ChangeStatus(r.Context(), cartID, request.Status)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cart); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// MergeCart handles merging another cart of the caller, typically the cart of their guest session,
+// into the cart. The source cart is deleted and the merged cart is returned.
+func (h *CartHandler) MergeCart(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.MergeCart")
+	defer span.End()
 	if r.Method != http.MethodPost {
-		http.Error(w, carterror.ErrInvalidRequestMethod.Error(), http.StatusMethodNotAllowed)
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
 		return
 	}
 	cartID := strings.Split(r.URL.Path, "/")[2]
-	log.Println("Extracted id: ", cartID)
+	h.logger.DebugContext(r.Context(), "merge cart", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+
+	var request struct {
+		SourceCartID string `json:"source_cart_id"`
+	}
+
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	v.UUID("source_cart_id", request.SourceCartID)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+	if !authorizeSourceCart(w, r, h.cartService, request.SourceCartID) {
+		return
+	}
+
+	cart, err := h.cartService.MergeCarts(r.Context(), cartID, request.SourceCartID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cart); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
 
-	if cartID == "" {
-		http.Error(w, carterror.ErrInvalidQuery.Error(), http.StatusBadRequest)
+// AddToCart handles the addition of an item to the cart.
+// With If-Match, the item is only added if the cart has not changed since the client read it.
+func (h *CartHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.AddToCart")
+	defer span.End()
+	if r.Method != http.MethodPost {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
 		return
 	}
+	cartID := strings.Split(r.URL.Path, "/")[2]
+	h.logger.DebugContext(r.Context(), "add item to cart", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
 
 	var request struct {
-		Product  string `json:"product"`
+		SKU      string `json:"sku"`
 		Quantity int    `json:"quantity"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, carterror.ErrInvalidRequestBody.Error(), http.StatusBadRequest)
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if v.Required("sku", request.SKU) {
+		v.MaxLength("sku", request.SKU, validation.MaxSKULength)
+	}
+	v.Range("quantity", request.Quantity, 1, validation.MaxQuantity)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
 		return
 	}
 
-	item := model.CartItem{ID: "", CartID: cartID, Product: request.Product, Quantity: request.Quantity}
-	err := h.cartItemService.AddToCart(r.Context(), &item)
+	item := model.CartItem{ID: "", CartID: cartID, SKU: request.SKU, Quantity: request.Quantity}
+	merged, err := h.cartItemService.AddToCart(r.Context(), &item, ifMatch(r))
+	if errors.Is(err, carterror.ErrProductDoesNotExist) {
+		writeErrorStatus(w, r, err, http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeChangeError(w, r, err)
 		return
 	}
 
+	response := struct {
+		model.CartItem
+		Merged bool `json:"merged"`
+	}{CartItem: item, Merged: merged}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(item); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		writeError(w, r, err)
 		return
 	}
 }
 
 // RemoveFromCart handles the removal of an item from the cart.
+// With If-Match, the item is only removed if the cart has not changed since the client read it.
 func (h *CartHandler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
-	log.Println("RemoveFromCart is called")
+	r, span := startSpan(r, "CartHandler.RemoveFromCart")
+	defer span.End()
 	if r.Method != http.MethodDelete {
-		http.Error(w, carterror.ErrInvalidRequestMethod.Error(), http.StatusMethodNotAllowed)
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
 		return
 	}
 	paths := strings.Split(r.URL.Path, "/")
 	cartID := paths[2]
 	itemID := paths[4]
-	log.Println("Extracted cartID: ", cartID)
-	log.Println("Extracted itemID: ", itemID)
+	h.logger.DebugContext(r.Context(), "remove item from cart", "cart_id", cartID, "item_id", itemID)
 
-	if cartID == "" {
-		http.Error(w, carterror.ErrCartIDRequired.Error(), http.StatusBadRequest)
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	v.UUID("item_id", itemID)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
-	if itemID == "" {
-		http.Error(w, carterror.ErrItemIDRequired.Error(), http.StatusBadRequest)
+	if !authorizeCart(w, r, h.cartService, cartID) {
 		return
 	}
 
-	err := h.cartItemService.RemoveFromCart(r.Context(), cartID, itemID)
+	err := h.cartItemService.RemoveFromCart(r.Context(), cartID, itemID, ifMatch(r))
 	if err != nil {
-		if err.Error() == "cart does not exist" {
-			http.Error(w, carterror.ErrCartDoesNotExist.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		writeChangeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateQuantity handles changing the quantity of an item in the cart.
+// Setting the quantity to zero removes the item.
+// With If-Match, the quantity is only changed if the cart has not changed since the client read it.
+func (h *CartHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.UpdateQuantity")
+	defer span.End()
+	if r.Method != http.MethodPatch {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	paths := strings.Split(r.URL.Path, "/")
+	cartID := paths[2]
+	itemID := paths[4]
+	h.logger.DebugContext(r.Context(), "update item quantity", "cart_id", cartID, "item_id", itemID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	v.UUID("item_id", itemID)
+
+	var request struct {
+		Quantity *int `json:"quantity"`
+	}
+
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if request.Quantity == nil {
+		v.Add("quantity", validation.CodeRequired, "must be set")
+	} else {
+		v.Range("quantity", *request.Quantity, 0, validation.MaxQuantity)
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+
+	item, err := h.cartItemService.UpdateQuantity(r.Context(), cartID, itemID, *request.Quantity, ifMatch(r))
+	if err != nil {
+		writeChangeError(w, r, err)
+		return
+	}
+	if item == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(item); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// ApplyCoupon handles applying a coupon to the cart.
+// It responds with the repriced cart, or with the reason the coupon was rejected.
+func (h *CartHandler) ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.ApplyCoupon")
+	defer span.End()
+	if r.Method != http.MethodPost {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	cartID := strings.Split(r.URL.Path, "/")[2]
+	h.logger.DebugContext(r.Context(), "apply coupon", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+
+	var request struct {
+		Code string `json:"code"`
+	}
+
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if v.Required("code", request.Code) {
+		v.MaxLength("code", request.Code, validation.MaxCouponLength)
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+
+	cart, err := h.cartService.ApplyCoupon(r.Context(), cartID, request.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cart); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// RemoveCoupon handles removing a coupon from the cart.
+func (h *CartHandler) RemoveCoupon(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CartHandler.RemoveCoupon")
+	defer span.End()
+	if r.Method != http.MethodDelete {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	paths := strings.Split(r.URL.Path, "/")
+	cartID := paths[2]
+	code := paths[4]
+	h.logger.DebugContext(r.Context(), "remove coupon", "cart_id", cartID, "code", code)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	if v.Required("code", code) {
+		v.MaxLength("code", code, validation.MaxCouponLength)
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.cartService, cartID) {
+		return
+	}
+
+	if err := h.cartService.RemoveCoupon(r.Context(), cartID, code); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
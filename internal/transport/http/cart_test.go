@@ -2,13 +2,18 @@ package handler_test
 
 import (
 	"bytes"
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
 	"cart-api/internal/model"
 	handler "cart-api/internal/transport/http"
+	"cart-api/internal/transport/http/auth"
+	"cart-api/internal/transport/http/problem"
+	"cart-api/internal/validation"
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,8 +24,13 @@ type MockCartService struct {
 	mock.Mock
 }
 
-func (m *MockCartService) CreateCart(ctx context.Context) (*model.Cart, error) {
-	args := m.Called(ctx)
+func (m *MockCartService) CheckOwner(ctx context.Context, id string, owner model.Owner) error {
+	args := m.Called(ctx, id, owner)
+	return args.Error(0)
+}
+
+func (m *MockCartService) CreateCart(ctx context.Context, owner model.Owner) (*model.Cart, error) {
+	args := m.Called(ctx, owner)
 	return args.Get(0).(*model.Cart), args.Error(1)
 }
 
@@ -29,27 +39,64 @@ func (m *MockCartService) ViewCart(ctx context.Context, id string) (*model.Cart,
 	return args.Get(0).(*model.Cart), args.Error(1)
 }
 
+func (m *MockCartService) DeleteCart(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCartService) ClearCart(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCartService) ApplyCoupon(ctx context.Context, id, code string) (*model.Cart, error) {
+	args := m.Called(ctx, id, code)
+	return args.Get(0).(*model.Cart), args.Error(1)
+}
+
+func (m *MockCartService) RemoveCoupon(ctx context.Context, id, code string) error {
+	args := m.Called(ctx, id, code)
+	return args.Error(0)
+}
+
+func (m *MockCartService) ChangeStatus(ctx context.Context, id string, status model.CartStatus) (*model.Cart, error) {
+	args := m.Called(ctx, id, status)
+	return args.Get(0).(*model.Cart), args.Error(1)
+}
+
+func (m *MockCartService) MergeCarts(ctx context.Context, targetID, sourceID string) (*model.Cart, error) {
+	args := m.Called(ctx, targetID, sourceID)
+	return args.Get(0).(*model.Cart), args.Error(1)
+}
+
 type MockCartItemService struct {
 	mock.Mock
 }
 
-func (m *MockCartItemService) AddToCart(ctx context.Context, item *model.CartItem) error {
-	args := m.Called(ctx, item)
-	return args.Error(0)
+func (m *MockCartItemService) AddToCart(ctx context.Context, item *model.CartItem, ifMatch func(version int64) bool) (bool, error) {
+	args := m.Called(ctx, item, ifMatch)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockCartItemService) RemoveFromCart(ctx context.Context, CartID, CartItemID string) error {
-	args := m.Called(ctx, CartID, CartItemID)
+func (m *MockCartItemService) RemoveFromCart(ctx context.Context, CartID, CartItemID string, ifMatch func(version int64) bool) error {
+	args := m.Called(ctx, CartID, CartItemID, ifMatch)
 	return args.Error(0)
 }
 
+func (m *MockCartItemService) UpdateQuantity(ctx context.Context, CartID, CartItemID string, quantity int, ifMatch func(version int64) bool) (*model.CartItem, error) {
+	args := m.Called(ctx, CartID, CartItemID, quantity, ifMatch)
+	return args.Get(0).(*model.CartItem), args.Error(1)
+}
+
 func TestCreateCart(t *testing.T) {
 	mockCartService := new(MockCartService)
 	mockCartItemService := new(MockCartItemService)
-	h := handler.NewCartHandler(mockCartService, mockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
 
-	cart := &model.Cart{ID: "123", Items: []model.CartItem{}}
-	mockCartService.On("CreateCart", mock.Anything).Return(cart, nil)
+	cart := &model.Cart{ID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Items: []model.CartItem{}}
+	mockCartService.On("CreateCart", mock.Anything, mock.MatchedBy(func(owner model.Owner) bool {
+		return owner.Kind == model.OwnerSession && owner.ID != ""
+	})).Return(cart, nil)
 
 	r := httptest.NewRequest(http.MethodPost, "/carts", nil)
 	w := httptest.NewRecorder()
@@ -59,21 +106,63 @@ func TestCreateCart(t *testing.T) {
 	defer res.Body.Close()
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotEmpty(t, res.Header.Get(handler.SessionTokenHeader))
 	var got model.Cart
 	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-	assert.Equal(t, "123", got.ID)
+	assert.Equal(t, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", got.ID)
+}
+
+func TestCreateCart_BoundToUser(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	cart := &model.Cart{ID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Items: []model.CartItem{}}
+	mockCartService.On("CreateCart", mock.Anything, model.Owner{Kind: model.OwnerUser, ID: "user-1"}).Return(cart, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/carts", nil)
+	r = r.WithContext(auth.WithSubject(r.Context(), "user-1"))
+	w := httptest.NewRecorder()
+
+	h.CreateCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, res.Header.Get(handler.SessionTokenHeader))
+	mockCartService.AssertExpectations(t)
+}
+
+func TestViewCart_ForeignCart(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	mockCartService.On("CheckOwner", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", mock.Anything).Return(carterror.ErrCartDoesNotExist)
+
+	r := httptest.NewRequest(http.MethodGet, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
+	r.Header.Set(handler.SessionTokenHeader, "someone-else")
+	w := httptest.NewRecorder()
+
+	h.ViewCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	mockCartService.AssertNotCalled(t, "ViewCart", mock.Anything, mock.Anything)
 }
 
 func TestViewCart_NotFound(t *testing.T) {
 	mockCartService := new(MockCartService)
 	mockCartItemService := new(MockCartItemService)
-	h := handler.NewCartHandler(mockCartService, mockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	mockCartService.On("ViewCart", mock.Anything, "123").Return((*model.Cart)(nil), errors.New("not found"))
+	mockCartService.On("ViewCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return((*model.Cart)(nil), carterror.ErrCartDoesNotExist)
 
-	r := httptest.NewRequest(http.MethodGet, "/carts/123", nil)
+	r := httptest.NewRequest(http.MethodGet, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
 	w := httptest.NewRecorder()
 
 	h.ViewCart(w, r)
@@ -83,16 +172,77 @@ func TestViewCart_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, res.StatusCode)
 }
 
+func TestViewCart_ETag(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	cart := &model.Cart{ID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Status: model.CartActive, Version: 3}
+	mockCartService.On("ViewCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(cart, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
+	w := httptest.NewRecorder()
+
+	h.ViewCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+}
+
+func TestViewCart_NotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"current version", `"3"`, http.StatusNotModified},
+		{"weak current version", `W/"3"`, http.StatusNotModified},
+		{"one of several versions", `"1", "3"`, http.StatusNotModified},
+		{"any version", `*`, http.StatusNotModified},
+		{"stale version", `"2"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartService := new(MockCartService)
+			mockCartItemService := new(MockCartItemService)
+			h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+			mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			cart := &model.Cart{ID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Status: model.CartActive, Version: 3}
+			mockCartService.On("ViewCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(cart, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+			w := httptest.NewRecorder()
+
+			h.ViewCart(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+			if tt.wantStatus == http.StatusNotModified {
+				assert.Zero(t, w.Body.Len())
+			}
+		})
+	}
+}
+
 func TestAddToCart(t *testing.T) {
 	mockCartService := new(MockCartService)
 	mockCartItemService := new(MockCartItemService)
-	h := handler.NewCartHandler(mockCartService, mockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	item := model.CartItem{CartID: "123", Product: "Apple", Quantity: 2}
-	mockCartItemService.On("AddToCart", mock.Anything, &item).Return(nil)
+	item := model.CartItem{CartID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", SKU: "Apple", Quantity: 2}
+	mockCartItemService.On("AddToCart", mock.Anything, &item, mock.Anything).Return(true, nil)
 
-	body, _ := json.Marshal(item)
-	r := httptest.NewRequest(http.MethodPost, "/carts/123/items", bytes.NewReader(body))
+	body := bytes.NewBufferString(`{"sku": "Apple", "quantity": 2}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", body)
 	w := httptest.NewRecorder()
 
 	h.AddToCart(w, r)
@@ -100,16 +250,24 @@ func TestAddToCart(t *testing.T) {
 	defer res.Body.Close()
 
 	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var got struct {
+		Merged bool `json:"merged"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.True(t, got.Merged)
 }
 
 func TestRemoveFromCart(t *testing.T) {
 	mockCartService := new(MockCartService)
 	mockCartItemService := new(MockCartItemService)
-	h := handler.NewCartHandler(mockCartService, mockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
-	mockCartItemService.On("RemoveFromCart", mock.Anything, "123", "456").Return(nil)
+	mockCartItemService.On("RemoveFromCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", mock.Anything).Return(nil)
 
-	r := httptest.NewRequest(http.MethodDelete, "/carts/123/items/456", nil)
+	r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", nil)
 	w := httptest.NewRecorder()
 
 	h.RemoveFromCart(w, r)
@@ -118,3 +276,446 @@ func TestRemoveFromCart(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, res.StatusCode)
 }
+
+func TestRemoveFromCart_IfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{"current version", `"3"`, http.StatusNoContent},
+		{"any version", `*`, http.StatusNoContent},
+		{"stale version", `"2"`, http.StatusPreconditionFailed},
+		{"weak version", `W/"3"`, http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartService := new(MockCartService)
+			mockCartItemService := new(MockCartItemService)
+			h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+			mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			// The service checks the precondition against the locked cart, which is at version 3.
+			matchesCurrent := func(ifMatch func(version int64) bool) bool { return ifMatch != nil && ifMatch(3) }
+			mockCartItemService.On("RemoveFromCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", mock.MatchedBy(matchesCurrent)).
+				Return(nil)
+			mockCartItemService.On("RemoveFromCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", mock.Anything).
+				Return(&carterror.VersionError{Version: 3})
+
+			r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", nil)
+			r.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+
+			h.RemoveFromCart(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+				assert.Contains(t, w.Body.String(), "precondition_failed")
+			}
+		})
+	}
+}
+
+func TestUpdateQuantity(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	item := &model.CartItem{ID: "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", CartID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", SKU: "Apple", Quantity: 3}
+	mockCartItemService.On("UpdateQuantity", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", 3, mock.Anything).Return(item, nil)
+
+	r := httptest.NewRequest(http.MethodPatch, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", bytes.NewReader([]byte(`{"quantity": 3}`)))
+	w := httptest.NewRecorder()
+
+	h.UpdateQuantity(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var got model.CartItem
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", got.ID)
+	assert.Equal(t, 3, got.Quantity)
+}
+
+func TestUpdateQuantity_ZeroRemovesItem(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartItemService.On("UpdateQuantity", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", 0, mock.Anything).Return((*model.CartItem)(nil), nil)
+
+	r := httptest.NewRequest(http.MethodPatch, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", bytes.NewReader([]byte(`{"quantity": 0}`)))
+	w := httptest.NewRecorder()
+
+	h.UpdateQuantity(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestUpdateQuantity_ItemNotFound(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartItemService.On("UpdateQuantity", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", 3, mock.Anything).Return((*model.CartItem)(nil), carterror.ErrCartItemDoesNotExist)
+
+	r := httptest.NewRequest(http.MethodPatch, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", bytes.NewReader([]byte(`{"quantity": 3}`)))
+	w := httptest.NewRecorder()
+
+	h.UpdateQuantity(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestDeleteCart(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("DeleteCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(nil)
+
+	r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
+	w := httptest.NewRecorder()
+
+	h.DeleteCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestDeleteCart_NotFound(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("DeleteCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(carterror.ErrCartDoesNotExist)
+
+	r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
+	w := httptest.NewRecorder()
+
+	h.DeleteCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestDeleteCart_CheckedOut(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("DeleteCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(&carterror.StatusError{Status: "checked_out"})
+
+	r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff", nil)
+	w := httptest.NewRecorder()
+
+	h.DeleteCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+	assert.Contains(t, w.Body.String(), "cart_checked_out")
+}
+
+func TestClearCart(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("ClearCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(nil)
+
+	r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", nil)
+	w := httptest.NewRecorder()
+
+	h.ClearCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestApplyCoupon(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	cart := &model.Cart{ID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Discounts: []model.AppliedDiscount{{Code: "TEN", Kind: model.CouponPercentage, Amount: 100}}}
+	mockCartService.On("ApplyCoupon", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "TEN").Return(cart, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/coupons", bytes.NewReader([]byte(`{"code": "TEN"}`)))
+	w := httptest.NewRecorder()
+
+	h.ApplyCoupon(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var got model.Cart
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, cart.Discounts, got.Discounts)
+}
+
+func TestApplyCoupon_Rejected(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("ApplyCoupon", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "OLD").Return((*model.Cart)(nil), carterror.ErrCouponExpired)
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/coupons", bytes.NewReader([]byte(`{"code": "OLD"}`)))
+	w := httptest.NewRecorder()
+
+	h.ApplyCoupon(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+}
+
+func TestRemoveCoupon(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("RemoveCoupon", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "TEN").Return(nil)
+
+	r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/coupons/TEN", nil)
+	w := httptest.NewRecorder()
+
+	h.RemoveCoupon(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestAddToCart_CartNotActive(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartItemService.On("AddToCart", mock.Anything, mock.Anything, mock.Anything).Return(false, &carterror.StatusError{Status: "locked"})
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", bytes.NewReader([]byte(`{"sku": "Apple", "quantity": 1}`)))
+	w := httptest.NewRecorder()
+
+	h.AddToCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestChangeStatus(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	cart := &model.Cart{ID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Status: model.CartLocked}
+	mockCartService.On("ChangeStatus", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", model.CartLocked).Return(cart, nil)
+
+	r := httptest.NewRequest(http.MethodPut, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/status", bytes.NewReader([]byte(`{"status": "locked"}`)))
+	w := httptest.NewRecorder()
+
+	h.ChangeStatus(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var got model.Cart
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, model.CartLocked, got.Status)
+}
+
+func TestChangeStatus_NotAllowed(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockCartService.On("ChangeStatus", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", model.CartActive).Return((*model.Cart)(nil), carterror.ErrInvalidStatusTransition)
+
+	r := httptest.NewRequest(http.MethodPut, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/status", bytes.NewReader([]byte(`{"status": "active"}`)))
+	w := httptest.NewRecorder()
+
+	h.ChangeStatus(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestMergeCart_GuestCartIntoUserCart(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	user := model.Owner{Kind: model.OwnerUser, ID: "user-1"}
+	mockCartService.On("CheckOwner", mock.Anything, "b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d", user).Return(nil)
+	mockCartService.On("CheckOwner", mock.Anything, "c4d2e3f5-6071-4b8c-9dae-1f2a3b4c5d6e", user).Return(carterror.ErrCartDoesNotExist)
+	mockCartService.On("CheckOwner", mock.Anything, "c4d2e3f5-6071-4b8c-9dae-1f2a3b4c5d6e", mock.MatchedBy(func(owner model.Owner) bool {
+		return owner.Kind == model.OwnerSession
+	})).Return(nil)
+	merged := &model.Cart{ID: "b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d", Items: []model.CartItem{{SKU: "product1", Quantity: 3}}}
+	mockCartService.On("MergeCarts", mock.Anything, "b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d", "c4d2e3f5-6071-4b8c-9dae-1f2a3b4c5d6e").Return(merged, nil)
+
+	body := bytes.NewBufferString(`{"source_cart_id": "c4d2e3f5-6071-4b8c-9dae-1f2a3b4c5d6e"}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d/merge", body)
+	r = r.WithContext(auth.WithSubject(r.Context(), "user-1"))
+	r.Header.Set(handler.SessionTokenHeader, "guest-token")
+	w := httptest.NewRecorder()
+
+	h.MergeCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var got model.Cart
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d", got.ID)
+	assert.Equal(t, 3, got.Items[0].Quantity)
+}
+
+func TestMergeCart_ForeignSourceCart(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	mockCartService.On("CheckOwner", mock.Anything, "b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d", mock.Anything).Return(nil)
+	mockCartService.On("CheckOwner", mock.Anything, "d5e3f406-7182-4c9d-aebf-2a3b4c5d6e7f", mock.Anything).Return(carterror.ErrCartDoesNotExist)
+
+	body := bytes.NewBufferString(`{"source_cart_id": "d5e3f406-7182-4c9d-aebf-2a3b4c5d6e7f"}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/b3c1d2e4-5f60-4a7b-8c9d-0e1f2a3b4c5d/merge", body)
+	r = r.WithContext(auth.WithSubject(r.Context(), "user-1"))
+	w := httptest.NewRecorder()
+
+	h.MergeCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	mockCartService.AssertNotCalled(t, "MergeCarts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddToCart_ValidationFailed(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	body := bytes.NewBufferString(`{"sku": "` + strings.Repeat("A", 65) + `", "quantity": 0}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/not-a-uuid/items", body)
+	w := httptest.NewRecorder()
+
+	h.AddToCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	var got problem.Details
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "validation_failed", got.Code)
+	assert.Equal(t, []validation.Violation{
+		{Field: "cart_id", Code: validation.CodeInvalidUUID, Message: "must be a UUID"},
+		{Field: "sku", Code: validation.CodeTooLong, Message: "must be at most 64 characters long"},
+		{Field: "quantity", Code: validation.CodeOutOfRange, Message: "must be between 1 and 999"},
+	}, got.Violations)
+	mockCartService.AssertNotCalled(t, "CheckOwner", mock.Anything, mock.Anything, mock.Anything)
+	mockCartItemService.AssertNotCalled(t, "AddToCart", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddToCart_UnknownField(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	body := bytes.NewBufferString(`{"sku": "", "quantity": 0, "unit_price": 1, "currency": "USD"}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", body)
+	w := httptest.NewRecorder()
+
+	h.AddToCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	var got problem.Details
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, []validation.Violation{
+		{Field: "currency", Code: validation.CodeUnknownField, Message: "is not a known field"},
+		{Field: "unit_price", Code: validation.CodeUnknownField, Message: "is not a known field"},
+		{Field: "sku", Code: validation.CodeRequired, Message: "must not be empty"},
+		{Field: "quantity", Code: validation.CodeOutOfRange, Message: "must be between 1 and 999"},
+	}, got.Violations)
+	mockCartItemService.AssertNotCalled(t, "AddToCart", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAddToCart_FieldNamesAreCaseInsensitive(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	body := bytes.NewBufferString(`{"SKU": "Apple", "quantity": 0}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", body)
+	w := httptest.NewRecorder()
+
+	h.AddToCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	var got problem.Details
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, []validation.Violation{
+		{Field: "quantity", Code: validation.CodeOutOfRange, Message: "must be between 1 and 999"},
+	}, got.Violations)
+}
+
+func TestAddToCart_BodyTooLarge(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+
+	body := bytes.NewBufferString(`{"sku": "` + strings.Repeat("A", 100<<10) + `", "quantity": 1}`)
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", body)
+	w := httptest.NewRecorder()
+
+	h.AddToCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
+	"cart-api/internal/transport/http/problem"
+	"cart-api/internal/validation"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errorMapping ties a carterror sentinel to the HTTP status and the stable error code it is reported with.
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings lists the known errors in the order they are matched with errors.Is.
+var errorMappings = []errorMapping{
+	{carterror.ErrInvalidRequestMethod, http.StatusMethodNotAllowed, "method_not_allowed"},
+	{carterror.ErrInvalidRequestBody, http.StatusBadRequest, "invalid_request_body"},
+	{carterror.ErrRequestBodyTooLarge, http.StatusRequestEntityTooLarge, "request_body_too_large"},
+	{carterror.ErrInvalidQuery, http.StatusBadRequest, "invalid_query"},
+	{carterror.ErrCartIDRequired, http.StatusBadRequest, "cart_id_required"},
+	{carterror.ErrItemIDRequired, http.StatusBadRequest, "item_id_required"},
+	{carterror.ErrSourceCartIDRequired, http.StatusBadRequest, "source_cart_id_required"},
+	{carterror.ErrMergeIntoItself, http.StatusBadRequest, "merge_into_itself"},
+	{carterror.ErrMissingSKU, http.StatusBadRequest, "missing_sku"},
+	{carterror.ErrQuantityMustBePositive, http.StatusBadRequest, "invalid_quantity"},
+	{carterror.ErrQuantityTooLarge, http.StatusBadRequest, "invalid_quantity"},
+	{carterror.ErrMissingProductName, http.StatusBadRequest, "missing_product_name"},
+	{carterror.ErrPriceMustNotBeNegative, http.StatusBadRequest, "invalid_price"},
+	{carterror.ErrInvalidCurrency, http.StatusBadRequest, "invalid_currency"},
+	{carterror.ErrMissingCouponCode, http.StatusBadRequest, "missing_coupon_code"},
+	{carterror.ErrInvalidStatus, http.StatusBadRequest, "invalid_status"},
+	{carterror.ErrNotFound, http.StatusNotFound, "not_found"},
+	{carterror.ErrCartDoesNotExist, http.StatusNotFound, "cart_not_found"},
+	{carterror.ErrCartItemDoesNotExist, http.StatusNotFound, "cart_item_not_found"},
+	{carterror.ErrProductDoesNotExist, http.StatusNotFound, "product_not_found"},
+	{carterror.ErrCouponDoesNotExist, http.StatusNotFound, "coupon_not_found"},
+	{carterror.ErrCouponNotApplied, http.StatusNotFound, "coupon_not_applied"},
+	{carterror.ErrCartCheckedOut, http.StatusConflict, "cart_checked_out"},
+	{carterror.ErrCartNotActive, http.StatusConflict, "cart_not_active"},
+	{carterror.ErrInvalidStatusTransition, http.StatusConflict, "invalid_status_transition"},
+	{carterror.ErrProductAlreadyExists, http.StatusConflict, "product_already_exists"},
+	{carterror.ErrProductInUse, http.StatusConflict, "product_in_use"},
+	{carterror.ErrProductInactive, http.StatusUnprocessableEntity, "product_inactive"},
+	{carterror.ErrCurrencyMismatch, http.StatusUnprocessableEntity, "currency_mismatch"},
+	{carterror.ErrCouponNotYetValid, http.StatusUnprocessableEntity, "coupon_not_yet_valid"},
+	{carterror.ErrCouponExpired, http.StatusUnprocessableEntity, "coupon_expired"},
+	{carterror.ErrCouponExhausted, http.StatusUnprocessableEntity, "coupon_exhausted"},
+	{carterror.ErrCouponBelowMinimum, http.StatusUnprocessableEntity, "coupon_below_minimum"},
+	{carterror.ErrCouponNotApplicable, http.StatusUnprocessableEntity, "coupon_not_applicable"},
+	{carterror.ErrCartEmpty, http.StatusUnprocessableEntity, "cart_empty"},
+	{carterror.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+	{carterror.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{carterror.ErrIdempotencyInProgress, http.StatusConflict, "idempotency_request_in_progress"},
+}
+
+// writeError writes err as problem details with the status and code of the first matching sentinel.
+// Validation errors are reported with 422 and the list of violations.
+// Unknown errors are logged with the request and reported as an internal error without exposing their message.
+// The error is recorded on the span of the request; only internal errors mark the span as failed.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	span := trace.SpanFromContext(r.Context())
+	span.RecordError(err)
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		p := problem.New(r, http.StatusUnprocessableEntity, "validation_failed", "request validation failed")
+		p.Violations = violations
+		problem.WriteDetails(w, p)
+		return
+	}
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			problem.Write(w, r, m.status, m.code, err.Error())
+			return
+		}
+	}
+	logging.RecordError(r.Context(), err)
+	span.SetStatus(codes.Error, err.Error())
+	problem.Write(w, r, http.StatusInternalServerError, "internal_error", "Internal server error")
+}
+
+// writeErrorStatus writes err like writeError but with the given status,
+// for errors whose meaning depends on the operation, e.g. an unknown product
+// is not found on the catalog routes but makes an item unprocessable when added to a cart.
+func writeErrorStatus(w http.ResponseWriter, r *http.Request, err error, status int) {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			trace.SpanFromContext(r.Context()).RecordError(err)
+			problem.Write(w, r, status, m.code, err.Error())
+			return
+		}
+	}
+	writeError(w, r, err)
+}
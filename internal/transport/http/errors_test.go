@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"bytes"
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
+	handler "cart-api/internal/transport/http"
+	"cart-api/internal/transport/http/problem"
+	"cart-api/internal/transport/http/requestid"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestErrorResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"cart not found", carterror.ErrCartDoesNotExist, http.StatusNotFound, "cart_not_found", "cart doesn't exist"},
+		{"wrapped item not found", errors.Join(errors.New("Delete"), carterror.ErrCartItemDoesNotExist), http.StatusNotFound, "cart_item_not_found", ""},
+		{"cart not active", &carterror.StatusError{Status: "locked"}, http.StatusConflict, "cart_not_active", "cart is locked"},
+		{"cart checked out", &carterror.StatusError{Status: "checked_out"}, http.StatusConflict, "cart_checked_out", "cart is checked_out"},
+		{"unknown error", errors.New("connection refused"), http.StatusInternalServerError, "internal_error", "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCartService := new(MockCartService)
+			mockCartItemService := new(MockCartItemService)
+			h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+			mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			mockCartItemService.On("RemoveFromCart", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", "9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", mock.Anything).Return(tt.err)
+
+			r := httptest.NewRequest(http.MethodDelete, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", nil)
+			r.Header.Set(requestid.Header, "request-1")
+			w := httptest.NewRecorder()
+
+			requestid.Middleware(http.HandlerFunc(h.RemoveFromCart)).ServeHTTP(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, problem.ContentType, res.Header.Get("Content-Type"))
+			var got problem.Details
+			if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.wantCode, got.Code)
+			assert.Equal(t, "request-1", got.RequestID)
+			assert.Equal(t, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items/9a1f6c2e-3d4b-4c5a-8e7f-1a2b3c4d5e6f", got.Instance)
+			if tt.wantDetail != "" {
+				assert.Equal(t, tt.wantDetail, got.Detail)
+			}
+		})
+	}
+}
+
+func TestErrorResponses_ProductNotFoundWhenAddingToCart(t *testing.T) {
+	mockCartService := new(MockCartService)
+	mockCartItemService := new(MockCartItemService)
+	h := handler.NewCartHandler(mockCartService, mockCartItemService, logging.Discard())
+	mockCartService.On("CheckOwner", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockCartItemService.On("AddToCart", mock.Anything, mock.AnythingOfType("*model.CartItem"), mock.Anything).
+		Return(false, carterror.ErrProductDoesNotExist)
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/items", bytes.NewBufferString(`{"sku": "missing", "quantity": 1}`))
+	w := httptest.NewRecorder()
+
+	h.AddToCart(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	var got problem.Details
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "product_not_found", got.Code)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"cart-api/internal/carterror"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// cartETag returns the strong entity tag of a cart version.
+func cartETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagMatches reports whether the comma separated list of entity tags in header contains etag or is "*".
+// Under weak comparison, used by If-None-Match, weak tags match their strong counterparts;
+// under strong comparison, used by If-Match, weak tags never match.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the If-Match precondition of a request that changes the cart.
+// The service verifies it against the version of the cart while the cart is locked,
+// so that the cart cannot change between the check and the write.
+// Requests without the header are not conditional and get a nil precondition.
+func ifMatch(r *http.Request) func(version int64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	return func(version int64) bool {
+		return etagMatches(header, cartETag(version), false)
+	}
+}
+
+// writeChangeError writes the error of a change to the cart like writeError.
+// If the If-Match precondition failed, the entity tag of the current version is included
+// so that the client can read the cart again and retry.
+func writeChangeError(w http.ResponseWriter, r *http.Request, err error) {
+	var versionErr *carterror.VersionError
+	if errors.As(err, &versionErr) {
+		w.Header().Set("ETag", cartETag(versionErr.Version))
+	}
+	writeError(w, r, err)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/transport/http/recorder"
+	"cart-api/internal/validation"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// IdempotencyKeyHeader is the header that carries the client generated key of a retryable request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses that are replayed for a retried request.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the length of idempotency keys.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders lists the response headers that are recorded and replayed with the response body.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// anonymousReplayedHeaders also lists the session token that CreateCart issues to an anonymous caller,
+// which cannot access the created cart without it. It is only replayed to the same client.
+var anonymousReplayedHeaders = []string{"Content-Type", "Location", SessionTokenHeader}
+
+// IdempotencyService defines the interface for recording and replaying responses by idempotency key.
+type IdempotencyService interface {
+	Begin(ctx context.Context, scope, key, requestHash string) (*model.RecordedResponse, error)
+	Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// IdempotencyHandler makes handlers safe to retry with the IdempotencyKeyHeader.
+type IdempotencyHandler struct {
+	service IdempotencyService
+	logger  *slog.Logger
+}
+
+// NewIdempotencyHandler creates a new instance of IdempotencyHandler that logs to logger.
+func NewIdempotencyHandler(s IdempotencyService, logger *slog.Logger) *IdempotencyHandler {
+	return &IdempotencyHandler{service: s, logger: logger}
+}
+
+// Wrap returns a handler that records the response of next for requests with an idempotency key
+// and replays it when the request is retried with the same key.
+// Keys are scoped to the caller; reusing a key for a different request is rejected with 422.
+// Requests without the header are passed to next unchanged.
+func (h *IdempotencyHandler) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var v validation.Errors
+		v.MaxLength(IdempotencyKeyHeader, key, maxIdempotencyKeyLength)
+		if err := v.Err(); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeError(w, r, carterror.ErrRequestBodyTooLarge)
+				return
+			}
+			writeError(w, r, carterror.ErrInvalidRequestBody)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope, anonymous := idempotencyScope(r)
+		recorded, err := h.service.Begin(r.Context(), scope, key, requestHash(r, body))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if recorded != nil {
+			replay(w, recorded)
+			return
+		}
+
+		rec := recorder.NewWithBody(w)
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := h.service.Release(context.WithoutCancel(r.Context()), scope, key); err != nil {
+				h.logger.ErrorContext(r.Context(), "release idempotency key", "error", err)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		// Server errors are not recorded, so the client can retry the request with the same key.
+		if rec.Status() >= http.StatusInternalServerError {
+			return
+		}
+		response := model.RecordedResponse{StatusCode: rec.Status(), Header: map[string]string{}, Body: rec.Body()}
+		headers := replayedHeaders
+		if anonymous {
+			headers = anonymousReplayedHeaders
+		}
+		for _, name := range headers {
+			if value := w.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+		if err := h.service.Complete(context.WithoutCancel(r.Context()), scope, key, response); err != nil {
+			h.logger.ErrorContext(r.Context(), "record idempotent response", "error", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// idempotencyScope returns the namespace of the keys of the caller, so that callers cannot replay each other's responses.
+// Anonymous callers, such as a client retrying the creation of its first cart, have no identity yet;
+// their keys are scoped to a fingerprint of the client instead, and anonymous is reported as true.
+func idempotencyScope(r *http.Request) (scope string, anonymous bool) {
+	owner := requestOwner(r)
+	if owner.ID == "" {
+		return "anonymous:" + clientFingerprint(r), true
+	}
+	return string(owner.Kind) + ":" + owner.ID, false
+}
+
+// clientFingerprint identifies the client of an anonymous request by its address and user agent.
+func clientFingerprint(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	sum := sha256.Sum256([]byte(host + "\n" + r.UserAgent()))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestHash fingerprints the request, so that a key reused for a different request can be detected.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\n"+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay writes a recorded response.
+func replay(w http.ResponseWriter, recorded *model.RecordedResponse) {
+	for name, value := range recorded.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(IdempotentReplayedHeader, strconv.FormatBool(true))
+	w.WriteHeader(recorded.StatusCode)
+	w.Write(recorded.Body)
+}
@@ -0,0 +1,182 @@
+package handler_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
+	"cart-api/internal/model"
+	handler "cart-api/internal/transport/http"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIdempotencyService keeps recorded responses in memory like the repository keeps them in the database.
+type fakeIdempotencyService struct {
+	hashes    map[string]string
+	responses map[string]*model.RecordedResponse
+	released  []string
+}
+
+func newFakeIdempotencyService() *fakeIdempotencyService {
+	return &fakeIdempotencyService{hashes: map[string]string{}, responses: map[string]*model.RecordedResponse{}}
+}
+
+func (f *fakeIdempotencyService) Begin(ctx context.Context, scope, key, requestHash string) (*model.RecordedResponse, error) {
+	hash, ok := f.hashes[scope+"/"+key]
+	if !ok {
+		f.hashes[scope+"/"+key] = requestHash
+		return nil, nil
+	}
+	if hash != requestHash {
+		return nil, carterror.ErrIdempotencyKeyReused
+	}
+	if f.responses[scope+"/"+key] == nil {
+		return nil, carterror.ErrIdempotencyInProgress
+	}
+	return f.responses[scope+"/"+key], nil
+}
+
+func (f *fakeIdempotencyService) Complete(ctx context.Context, scope, key string, response model.RecordedResponse) error {
+	f.responses[scope+"/"+key] = &response
+	return nil
+}
+
+func (f *fakeIdempotencyService) Release(ctx context.Context, scope, key string) error {
+	delete(f.hashes, scope+"/"+key)
+	f.released = append(f.released, key)
+	return nil
+}
+
+func countingHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(handler.SessionTokenHeader, "token")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":"cart-id"}`))
+	})
+}
+
+func idempotentRequest(key, body string) *http.Request {
+	r := anonymousIdempotentRequest(key, body)
+	r.Header.Set(handler.SessionTokenHeader, "guest-token")
+	return r
+}
+
+func anonymousIdempotentRequest(key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/carts", strings.NewReader(body))
+	r.Header.Set(handler.IdempotencyKeyHeader, key)
+	return r
+}
+
+func TestIdempotency_ReplaysResponse(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, idempotentRequest("key-1", `{}`))
+	second := httptest.NewRecorder()
+	h.ServeHTTP(second, idempotentRequest("key-1", `{}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Empty(t, second.Header().Get(handler.SessionTokenHeader))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, "true", second.Header().Get(handler.IdempotentReplayedHeader))
+	assert.Empty(t, first.Header().Get(handler.IdempotentReplayedHeader))
+}
+
+func TestIdempotency_KeyReusedForDifferentRequest(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", `{"sku":"Apple"}`))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, idempotentRequest("key-1", `{"sku":"Pear"}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_reused")
+}
+
+func TestIdempotency_AnonymousRetryIsReplayed(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, anonymousIdempotentRequest("key-1", `{}`))
+	second := httptest.NewRecorder()
+	h.ServeHTTP(second, anonymousIdempotentRequest("key-1", `{}`))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(handler.IdempotentReplayedHeader))
+	assert.Equal(t, "token", second.Header().Get(handler.SessionTokenHeader))
+}
+
+func TestIdempotency_AnonymousCallersDoNotShareKeys(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), anonymousIdempotentRequest("key-1", `{}`))
+	r := anonymousIdempotentRequest("key-1", `{}`)
+	r.RemoteAddr = "198.51.100.7:4711"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(handler.IdempotentReplayedHeader))
+}
+
+func TestIdempotency_KeysAreScopedToTheSession(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", `{}`))
+	r := idempotentRequest("key-1", `{}`)
+	r.Header.Set(handler.SessionTokenHeader, "other-guest-token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, w.Header().Get(handler.IdempotentReplayedHeader))
+}
+
+func TestIdempotency_ServerErrorIsNotRecorded(t *testing.T) {
+	calls := 0
+	service := newFakeIdempotencyService()
+	h := handler.NewIdempotencyHandler(service, logging.Discard()).Wrap(countingHandler(&calls, http.StatusInternalServerError))
+
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", `{}`))
+	h.ServeHTTP(httptest.NewRecorder(), idempotentRequest("key-1", `{}`))
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, []string{"key-1", "key-1"}, service.released)
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/carts", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/carts", nil))
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	calls := 0
+	h := handler.NewIdempotencyHandler(newFakeIdempotencyService(), logging.Discard()).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, idempotentRequest(strings.Repeat("k", 256), `{}`))
+
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// OrderService defines the interface for order-related operations.
+type OrderService interface {
+	Checkout(ctx context.Context, cartID string) (*model.Order, error)
+}
+
+// OrderHandler provides HTTP handlers for order operations.
+type OrderHandler struct {
+	orderService OrderService
+	owners       CartOwnership
+	logger       *slog.Logger
+}
+
+// NewOrderHandler creates a new instance of OrderHandler that logs to logger.
+// Carts can only be checked out by their owner, as verified by owners.
+func NewOrderHandler(o OrderService, owners CartOwnership, logger *slog.Logger) *OrderHandler {
+	return &OrderHandler{orderService: o, owners: owners, logger: logger}
+}
+
+// Checkout handles converting a cart into an order.
+func (h *OrderHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "OrderHandler.Checkout")
+	defer span.End()
+	if r.Method != http.MethodPost {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	cartID := strings.Split(r.URL.Path, "/")[2]
+	h.logger.DebugContext(r.Context(), "checkout", "cart_id", cartID)
+
+	var v validation.Errors
+	v.UUID("cart_id", cartID)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !authorizeCart(w, r, h.owners, cartID) {
+		return
+	}
+
+	order, err := h.orderService.Checkout(r.Context(), cartID)
+	if errors.Is(err, carterror.ErrCouponExhausted) {
+		// Another order used up the coupon while this cart was being checked out.
+		writeErrorStatus(w, r, err, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
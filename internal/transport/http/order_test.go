@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
+	"cart-api/internal/model"
+	handler "cart-api/internal/transport/http"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrderService struct {
+	mock.Mock
+}
+
+func (m *MockOrderService) Checkout(ctx context.Context, cartID string) (*model.Order, error) {
+	args := m.Called(ctx, cartID)
+	return args.Get(0).(*model.Order), args.Error(1)
+}
+
+func TestCheckout(t *testing.T) {
+	mockOrderService := new(MockOrderService)
+	mockCartService := new(MockCartService)
+	mockCartService.On("CheckOwner", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", mock.Anything).Return(nil)
+	h := handler.NewOrderHandler(mockOrderService, mockCartService, logging.Discard())
+
+	order := &model.Order{ID: "order-id", CartID: "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", Total: 900, Currency: "USD"}
+	mockOrderService.On("Checkout", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return(order, nil)
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/checkout", nil)
+	w := httptest.NewRecorder()
+
+	h.Checkout(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	var got model.Order
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, "order-id", got.ID)
+}
+
+func TestCheckout_AlreadyCheckedOut(t *testing.T) {
+	mockOrderService := new(MockOrderService)
+	mockCartService := new(MockCartService)
+	mockCartService.On("CheckOwner", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", mock.Anything).Return(nil)
+	h := handler.NewOrderHandler(mockOrderService, mockCartService, logging.Discard())
+
+	mockOrderService.On("Checkout", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff").Return((*model.Order)(nil), carterror.ErrCartNotActive)
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/checkout", nil)
+	w := httptest.NewRecorder()
+
+	h.Checkout(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestCheckout_ForeignCart(t *testing.T) {
+	mockOrderService := new(MockOrderService)
+	mockCartService := new(MockCartService)
+	mockCartService.On("CheckOwner", mock.Anything, "6f9619ff-8b86-4d01-b42d-00cf4fc964ff", mock.Anything).Return(carterror.ErrCartDoesNotExist)
+	h := handler.NewOrderHandler(mockOrderService, mockCartService, logging.Discard())
+
+	r := httptest.NewRequest(http.MethodPost, "/carts/6f9619ff-8b86-4d01-b42d-00cf4fc964ff/checkout", nil)
+	w := httptest.NewRecorder()
+
+	h.Checkout(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	mockOrderService.AssertNotCalled(t, "Checkout", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/transport/http/auth"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// SessionTokenHeader is the header that carries the token of an anonymous session.
+// CreateCart issues a new token in this header when the caller has no identity yet.
+const SessionTokenHeader = "X-Session-Token"
+
+// CartOwnership defines the interface for checking who may access a cart.
+type CartOwnership interface {
+	CheckOwner(ctx context.Context, cartID string, owner model.Owner) error
+}
+
+// requestOwner resolves the identity of the caller: the user authenticated by the bearer token if there is one,
+// otherwise the anonymous session. It returns an empty owner if the caller has neither.
+func requestOwner(r *http.Request) model.Owner {
+	if userID, ok := auth.Subject(r.Context()); ok {
+		return model.Owner{Kind: model.OwnerUser, ID: userID}
+	}
+	if token := r.Header.Get(SessionTokenHeader); token != "" {
+		return sessionOwner(token)
+	}
+	return model.Owner{}
+}
+
+// callerOwners returns every identity the caller presents: the authenticated user and the anonymous session.
+// A shopper who has just logged in presents both, which lets them claim the carts of their guest session.
+func callerOwners(r *http.Request) []model.Owner {
+	var owners []model.Owner
+	if userID, ok := auth.Subject(r.Context()); ok {
+		owners = append(owners, model.Owner{Kind: model.OwnerUser, ID: userID})
+	}
+	if token := r.Header.Get(SessionTokenHeader); token != "" {
+		owners = append(owners, sessionOwner(token))
+	}
+	return owners
+}
+
+// sessionOwner returns the owner for a session token.
+// Only a hash of the token is stored, so a leaked carts table does not leak sessions.
+func sessionOwner(token string) model.Owner {
+	sum := sha256.Sum256([]byte(token))
+	return model.Owner{Kind: model.OwnerSession, ID: hex.EncodeToString(sum[:])}
+}
+
+// newSessionToken generates a random token for a new anonymous session.
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authorizeCart checks that the caller owns the cart and writes the error response if not.
+// Foreign carts are reported as not found to avoid revealing which cart IDs exist.
+func authorizeCart(w http.ResponseWriter, r *http.Request, owners CartOwnership, cartID string) bool {
+	if err := owners.CheckOwner(r.Context(), cartID, requestOwner(r)); err != nil {
+		writeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+// authorizeSourceCart checks that the caller owns the cart under any of the identities they present
+// and writes the error response if not.
+func authorizeSourceCart(w http.ResponseWriter, r *http.Request, owners CartOwnership, cartID string) bool {
+	for _, owner := range callerOwners(r) {
+		err := owners.CheckOwner(r.Context(), cartID, owner)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, carterror.ErrCartDoesNotExist) {
+			writeError(w, r, err)
+			return false
+		}
+	}
+	writeError(w, r, carterror.ErrCartDoesNotExist)
+	return false
+}
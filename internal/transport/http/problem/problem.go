@@ -0,0 +1,54 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"cart-api/internal/transport/http/requestid"
+	"cart-api/internal/validation"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Details is an RFC 7807 problem details object extended with a machine-readable error code,
+// the ID of the request that failed and, for invalid requests, the violations of the request fields.
+type Details struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+
+	Violations []validation.Violation `json:"violations,omitempty"`
+}
+
+// New builds the problem details of a failed request.
+func New(r *http.Request, status int, code, detail string) Details {
+	return Details{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: requestid.FromContext(r.Context()),
+	}
+}
+
+// Write writes problem details with the given status, code and detail as the response.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	WriteDetails(w, New(r, status, code, detail))
+}
+
+// WriteDetails writes the problem details as the response.
+func WriteDetails(w http.ResponseWriter, p Details) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		slog.Error("failed to write problem details", "error", err)
+	}
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"cart-api/internal/carterror"
+	"cart-api/internal/model"
+	"cart-api/internal/validation"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// ProductService defines the interface for product catalog operations.
+type ProductService interface {
+	CreateProduct(ctx context.Context, product *model.Product) error
+	GetProduct(ctx context.Context, sku string) (*model.Product, error)
+	ListProducts(ctx context.Context) ([]model.Product, error)
+	UpdateProduct(ctx context.Context, product *model.Product) error
+	DeleteProduct(ctx context.Context, sku string) error
+}
+
+// ProductHandler provides HTTP handlers for the product catalog.
+type ProductHandler struct {
+	productService ProductService
+	logger         *slog.Logger
+}
+
+// NewProductHandler creates a new instance of ProductHandler that logs to logger.
+func NewProductHandler(p ProductService, logger *slog.Logger) *ProductHandler {
+	return &ProductHandler{productService: p, logger: logger}
+}
+
+// productRequest is the request body accepted by CreateProduct and UpdateProduct.
+type productRequest struct {
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	UnitPrice int64  `json:"unit_price"`
+	Currency  string `json:"currency"`
+	Active    *bool  `json:"active"`
+}
+
+// validate records violations of the request fields that are not covered by the catalog rules.
+func (p productRequest) validate(v *validation.Errors) {
+	if v.Required("sku", p.SKU) {
+		v.MaxLength("sku", p.SKU, validation.MaxSKULength)
+	}
+	if v.Required("name", p.Name) {
+		v.MaxLength("name", p.Name, validation.MaxNameLength)
+	}
+}
+
+// toModel converts the request into a product, treating a missing active flag as true.
+func (p productRequest) toModel() model.Product {
+	active := true
+	if p.Active != nil {
+		active = *p.Active
+	}
+	return model.Product{SKU: p.SKU, Name: p.Name, UnitPrice: p.UnitPrice, Currency: p.Currency, Active: active}
+}
+
+// CreateProduct handles the creation of a new product.
+func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+
+	var v validation.Errors
+	var request productRequest
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	request.validate(&v)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	product := request.toModel()
+	if err := h.productService.CreateProduct(r.Context(), &product); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// ListProducts handles the retrieval of all products.
+func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+
+	products, err := h.productService.ListProducts(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(products); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// GetProduct handles the retrieval of a product by its SKU.
+func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	sku := r.URL.Path[len("/products/"):]
+	h.logger.DebugContext(r.Context(), "get product", "sku", sku)
+
+	product, err := h.productService.GetProduct(r.Context(), sku)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// UpdateProduct handles replacing the attributes of an existing product.
+// The SKU is taken from the path; a SKU in the body is ignored.
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	sku := r.URL.Path[len("/products/"):]
+	h.logger.DebugContext(r.Context(), "update product", "sku", sku)
+
+	var v validation.Errors
+	var request productRequest
+	if err := decodeBody(w, r, &request, &v); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	request.SKU = sku
+	request.validate(&v)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	product := request.toModel()
+	if err := h.productService.UpdateProduct(r.Context(), &product); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(product); err != nil {
+		writeError(w, r, err)
+		return
+	}
+}
+
+// DeleteProduct handles the removal of a product from the catalog.
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, r, carterror.ErrInvalidRequestMethod)
+		return
+	}
+	sku := r.URL.Path[len("/products/"):]
+	h.logger.DebugContext(r.Context(), "delete product", "sku", sku)
+
+	if err := h.productService.DeleteProduct(r.Context(), sku); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
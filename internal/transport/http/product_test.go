@@ -0,0 +1,155 @@
+package handler_test
+
+import (
+	"bytes"
+	"cart-api/internal/carterror"
+	"cart-api/internal/logging"
+	"cart-api/internal/model"
+	handler "cart-api/internal/transport/http"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProductService struct {
+	mock.Mock
+}
+
+func (m *MockProductService) CreateProduct(ctx context.Context, product *model.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductService) GetProduct(ctx context.Context, sku string) (*model.Product, error) {
+	args := m.Called(ctx, sku)
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockProductService) ListProducts(ctx context.Context) ([]model.Product, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
+func (m *MockProductService) UpdateProduct(ctx context.Context, product *model.Product) error {
+	args := m.Called(ctx, product)
+	return args.Error(0)
+}
+
+func (m *MockProductService) DeleteProduct(ctx context.Context, sku string) error {
+	args := m.Called(ctx, sku)
+	return args.Error(0)
+}
+
+func TestCreateProduct(t *testing.T) {
+	mockProductService := new(MockProductService)
+	h := handler.NewProductHandler(mockProductService, logging.Discard())
+
+	want := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 4999, Currency: "USD", Active: true}
+	mockProductService.On("CreateProduct", mock.Anything, want).Return(nil)
+
+	body := []byte(`{"sku": "SHOES-1", "name": "Shoes", "unit_price": 4999, "currency": "USD"}`)
+	r := httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.CreateProduct(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	mockProductService.AssertExpectations(t)
+}
+
+func TestCreateProduct_AlreadyExists(t *testing.T) {
+	mockProductService := new(MockProductService)
+	h := handler.NewProductHandler(mockProductService, logging.Discard())
+
+	mockProductService.On("CreateProduct", mock.Anything, mock.Anything).Return(carterror.ErrProductAlreadyExists)
+
+	body := []byte(`{"sku": "SHOES-1", "name": "Shoes", "unit_price": 4999, "currency": "USD"}`)
+	r := httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.CreateProduct(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestGetProduct(t *testing.T) {
+	mockProductService := new(MockProductService)
+	h := handler.NewProductHandler(mockProductService, logging.Discard())
+
+	product := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 4999, Currency: "USD", Active: true}
+	mockProductService.On("GetProduct", mock.Anything, "SHOES-1").Return(product, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/products/SHOES-1", nil)
+	w := httptest.NewRecorder()
+
+	h.GetProduct(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var got model.Product
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	assert.Equal(t, *product, got)
+}
+
+func TestGetProduct_NotFound(t *testing.T) {
+	mockProductService := new(MockProductService)
+	h := handler.NewProductHandler(mockProductService, logging.Discard())
+
+	mockProductService.On("GetProduct", mock.Anything, "SHOES-1").Return((*model.Product)(nil), carterror.ErrProductDoesNotExist)
+
+	r := httptest.NewRequest(http.MethodGet, "/products/SHOES-1", nil)
+	w := httptest.NewRecorder()
+
+	h.GetProduct(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestUpdateProduct_UsesPathSKU(t *testing.T) {
+	mockProductService := new(MockProductService)
+	h := handler.NewProductHandler(mockProductService, logging.Discard())
+
+	want := &model.Product{SKU: "SHOES-1", Name: "Shoes", UnitPrice: 3999, Currency: "USD", Active: false}
+	mockProductService.On("UpdateProduct", mock.Anything, want).Return(nil)
+
+	body := []byte(`{"sku": "OTHER", "name": "Shoes", "unit_price": 3999, "currency": "USD", "active": false}`)
+	r := httptest.NewRequest(http.MethodPut, "/products/SHOES-1", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.UpdateProduct(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	mockProductService.AssertExpectations(t)
+}
+
+func TestDeleteProduct(t *testing.T) {
+	mockProductService := new(MockProductService)
+	h := handler.NewProductHandler(mockProductService, logging.Discard())
+
+	mockProductService.On("DeleteProduct", mock.Anything, "SHOES-1").Return(nil)
+
+	r := httptest.NewRequest(http.MethodDelete, "/products/SHOES-1", nil)
+	w := httptest.NewRecorder()
+
+	h.DeleteProduct(w, r)
+	res := w.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
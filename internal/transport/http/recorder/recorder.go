@@ -0,0 +1,57 @@
+// Package recorder wraps an http.ResponseWriter to remember what a handler wrote to the response,
+// for middleware that logs, measures or replays responses.
+package recorder
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// Recorder passes a response through to the client while remembering its status code
+// and, if created with NewWithBody, a copy of its body.
+type Recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        *bytes.Buffer
+}
+
+// New returns a Recorder of the response written to w.
+// The status is 200 until the handler writes another one.
+func New(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// NewWithBody returns a Recorder of the response written to w that also keeps a copy of the body.
+func NewWithBody(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, status: http.StatusOK, body: &bytes.Buffer{}}
+}
+
+// Status returns the status code of the response.
+func (r *Recorder) Status() int {
+	return r.status
+}
+
+// Body returns the copy of the response body, or nil if the Recorder was not created with NewWithBody.
+func (r *Recorder) Body() []byte {
+	if r.body == nil {
+		return nil
+	}
+	return r.body.Bytes()
+}
+
+func (r *Recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	if r.body != nil {
+		r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,34 @@
+package recorder_test
+
+import (
+	"cart-api/internal/transport/http/recorder"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := recorder.New(w)
+
+	rec.WriteHeader(http.StatusCreated)
+	rec.WriteHeader(http.StatusInternalServerError)
+	rec.Write([]byte("body"))
+
+	assert.Equal(t, http.StatusCreated, rec.Status())
+	assert.Nil(t, rec.Body())
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "body", w.Body.String())
+}
+
+func TestRecorder_ImplicitStatus(t *testing.T) {
+	rec := recorder.NewWithBody(httptest.NewRecorder())
+
+	rec.Write([]byte("body"))
+	rec.WriteHeader(http.StatusInternalServerError)
+
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, []byte("body"), rec.Body())
+}
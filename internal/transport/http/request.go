@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"bytes"
+	"cart-api/internal/carterror"
+	"cart-api/internal/validation"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxBodyBytes bounds the size of request bodies.
+const maxBodyBytes = 64 << 10
+
+// decodeBody decodes the JSON request body into dst, which must point to a struct.
+// Bodies larger than maxBodyBytes and malformed JSON are rejected with an error.
+// Unknown fields are recorded in v and the remaining fields are still decoded,
+// so that the caller can report them together with the violations of the known fields.
+func decodeBody(w http.ResponseWriter, r *http.Request, dst interface{}, v *validation.Errors) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return carterror.ErrRequestBodyTooLarge
+	}
+	if err != nil {
+		return carterror.ErrInvalidRequestBody
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(dst); err != nil {
+		return carterror.ErrInvalidRequestBody
+	}
+	if decoder.More() {
+		return carterror.ErrInvalidRequestBody
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return carterror.ErrInvalidRequestBody
+	}
+	unknown := make([]string, 0, len(fields))
+	for field := range fields {
+		if !isKnownField(dst, field) {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	for _, field := range unknown {
+		v.Add(field, validation.CodeUnknownField, "is not a known field")
+	}
+	return nil
+}
+
+// isKnownField reports whether encoding/json would decode the JSON object key name into a field of dst.
+func isKnownField(dst interface{}, name string) bool {
+	t := reflect.TypeOf(dst).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch tag {
+		case "-":
+			continue
+		case "":
+			tag = field.Name
+		}
+		if strings.EqualFold(tag, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+// Package requestid assigns every HTTP request an ID that is echoed in the response
+// and attached to error responses and logs.
+package requestid
+
+import (
+	"cart-api/internal/logging"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+)
+
+// Header is the header that carries the request ID.
+// An ID sent by the client or a proxy is kept; otherwise a new one is generated.
+const Header = "X-Request-ID"
+
+// maxLength bounds the length of request IDs accepted from clients.
+const maxLength = 128
+
+type requestIDKey struct{}
+
+// FromContext returns the ID of the request ctx belongs to, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware puts the request ID into the request context, its log attributes and the response header.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if id == "" || len(id) > maxLength {
+			id = newID()
+		}
+		w.Header().Set(Header, id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(logging.With(ctx, slog.String("request_id", id))))
+	})
+}
+
+// newID generates a random request ID.
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
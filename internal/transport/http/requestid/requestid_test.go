@@ -0,0 +1,32 @@
+package requestid_test
+
+import (
+	"cart-api/internal/transport/http/requestid"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	h := requestid.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/carts/123", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.NotEmpty(t, seen)
+	assert.Equal(t, seen, w.Header().Get(requestid.Header))
+
+	r = httptest.NewRequest(http.MethodGet, "/carts/123", nil)
+	r.Header.Set(requestid.Header, "from-proxy")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, "from-proxy", seen)
+	assert.Equal(t, "from-proxy", w.Header().Get(requestid.Header))
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the spans of the handler methods.
+var tracer = otel.Tracer("cart-api/internal/transport/http")
+
+// startSpan starts the span of a handler method and returns the request carrying the span in its context.
+func startSpan(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx, span := tracer.Start(r.Context(), name)
+	return r.WithContext(ctx), span
+}
@@ -0,0 +1,99 @@
+// Package validation collects field-level violations of request input
+// so that all of them can be reported to the client at once.
+package validation
+
+import (
+	"cart-api/internal/model"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Limits of the values accepted from clients.
+const (
+	MaxQuantity     = model.MaxItemQuantity
+	MaxSKULength    = 64
+	MaxNameLength   = 255
+	MaxCouponLength = 64
+)
+
+// Violation codes.
+const (
+	CodeRequired     = "required"
+	CodeTooLong      = "too_long"
+	CodeOutOfRange   = "out_of_range"
+	CodeInvalidUUID  = "invalid_uuid"
+	CodeUnknownField = "unknown_field"
+)
+
+// Violation describes why the value of a single field was rejected.
+type Violation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of violations. It implements error so that it can be returned alongside other errors.
+type Errors []Violation
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, v := range e {
+		messages = append(messages, v.Field+": "+v.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Err returns the violations as an error, or nil if there are none.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Add records a violation of field.
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, Violation{Field: field, Code: code, Message: message})
+}
+
+// Required records a violation if value is empty.
+func (e *Errors) Required(field, value string) bool {
+	if value == "" {
+		e.Add(field, CodeRequired, "must not be empty")
+		return false
+	}
+	return true
+}
+
+// MaxLength records a violation if value is longer than max characters.
+func (e *Errors) MaxLength(field, value string, max int) bool {
+	if len([]rune(value)) > max {
+		e.Add(field, CodeTooLong, fmt.Sprintf("must be at most %d characters long", max))
+		return false
+	}
+	return true
+}
+
+// Range records a violation if value is not within [min, max].
+func (e *Errors) Range(field string, value, min, max int) bool {
+	if value < min || value > max {
+		e.Add(field, CodeOutOfRange, fmt.Sprintf("must be between %d and %d", min, max))
+		return false
+	}
+	return true
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// UUID records a violation if value is not a UUID in its canonical textual form.
+func (e *Errors) UUID(field, value string) bool {
+	if !e.Required(field, value) {
+		return false
+	}
+	if !uuidPattern.MatchString(value) {
+		e.Add(field, CodeInvalidUUID, "must be a UUID")
+		return false
+	}
+	return true
+}
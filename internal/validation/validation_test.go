@@ -0,0 +1,38 @@
+package validation_test
+
+import (
+	"cart-api/internal/validation"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	var v validation.Errors
+	assert.NoError(t, v.Err())
+
+	assert.True(t, v.UUID("cart_id", "6F9619FF-8B86-4D01-B42D-00CF4FC964FF"))
+	assert.False(t, v.UUID("item_id", "6f9619ff8b864d01b42d00cf4fc964ff"))
+	assert.False(t, v.Required("sku", ""))
+	assert.False(t, v.MaxLength("name", "ÄÖÜ", 2))
+	assert.True(t, v.MaxLength("name", "ÄÖ", 2))
+	assert.False(t, v.Range("quantity", 1000, 1, validation.MaxQuantity))
+
+	err := v.Err()
+	var violations validation.Errors
+	assert.True(t, errors.As(err, &violations))
+	assert.Equal(t, []string{"item_id", "sku", "name", "quantity"}, fields(violations))
+	assert.Equal(t, validation.CodeInvalidUUID, violations[0].Code)
+	assert.Equal(t, validation.CodeRequired, violations[1].Code)
+	assert.Equal(t, validation.CodeTooLong, violations[2].Code)
+	assert.Equal(t, validation.CodeOutOfRange, violations[3].Code)
+}
+
+func fields(violations validation.Errors) []string {
+	var fields []string
+	for _, v := range violations {
+		fields = append(fields, v.Field)
+	}
+	return fields
+}
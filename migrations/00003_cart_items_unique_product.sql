@@ -0,0 +1,23 @@
+-- +goose Up
+-- Collapse lines that already share a product before the constraint is added.
+UPDATE cart_items ci
+SET quantity = dup.total
+FROM (
+    SELECT MIN(id::text)::uuid AS keep_id, SUM(quantity) AS total
+    FROM cart_items
+    GROUP BY cart_id, product
+    HAVING COUNT(*) > 1
+) dup
+WHERE ci.id = dup.keep_id;
+
+DELETE FROM cart_items ci
+USING cart_items other
+WHERE ci.cart_id = other.cart_id
+  AND ci.product = other.product
+  AND ci.id::text > other.id::text;
+
+ALTER TABLE cart_items
+    ADD CONSTRAINT cart_items_cart_id_product_key UNIQUE (cart_id, product);
+
+-- +goose Down
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_cart_id_product_key;
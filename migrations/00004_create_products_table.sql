@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE products (
+    sku TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    unit_price BIGINT NOT NULL CHECK (unit_price >= 0),
+    currency CHAR(3) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Free-text products already in carts become inactive catalog entries,
+-- so existing lines keep a valid reference but nothing new can be added with them.
+INSERT INTO products (sku, name, unit_price, currency, active)
+SELECT DISTINCT product, product, 0, 'USD', FALSE FROM cart_items
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE cart_items RENAME COLUMN product TO sku;
+ALTER TABLE cart_items RENAME CONSTRAINT cart_items_cart_id_product_key TO cart_items_cart_id_sku_key;
+ALTER TABLE cart_items
+    ADD CONSTRAINT cart_items_sku_fkey FOREIGN KEY (sku) REFERENCES products(sku);
+
+-- +goose Down
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_sku_fkey;
+ALTER TABLE cart_items RENAME CONSTRAINT cart_items_cart_id_sku_key TO cart_items_cart_id_product_key;
+ALTER TABLE cart_items RENAME COLUMN sku TO product;
+DROP TABLE products;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE cart_items
+    ADD COLUMN unit_price BIGINT NOT NULL DEFAULT 0 CHECK (unit_price >= 0),
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Lines added before prices existed take the current catalog price.
+UPDATE cart_items ci
+SET unit_price = p.unit_price, currency = p.currency
+FROM products p
+WHERE ci.sku = p.sku;
+
+ALTER TABLE cart_items
+    ALTER COLUMN unit_price DROP DEFAULT,
+    ALTER COLUMN currency DROP DEFAULT;
+
+-- +goose Down
+ALTER TABLE cart_items
+    DROP COLUMN unit_price,
+    DROP COLUMN currency;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE coupons (
+    code TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('percentage', 'fixed_amount', 'buy_x_get_y', 'free_shipping')),
+    percent_off INT CHECK (percent_off BETWEEN 1 AND 100),
+    amount_off BIGINT CHECK (amount_off > 0),
+    currency CHAR(3),
+    sku TEXT REFERENCES products(sku),
+    buy_quantity INT CHECK (buy_quantity > 0),
+    get_quantity INT CHECK (get_quantity > 0),
+    min_subtotal BIGINT NOT NULL DEFAULT 0 CHECK (min_subtotal >= 0),
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    usage_limit INT CHECK (usage_limit >= 0),
+    times_used INT NOT NULL DEFAULT 0,
+    CHECK (kind <> 'percentage' OR percent_off IS NOT NULL),
+    CHECK (kind <> 'fixed_amount' OR (amount_off IS NOT NULL AND currency IS NOT NULL)),
+    CHECK (kind <> 'buy_x_get_y' OR (sku IS NOT NULL AND buy_quantity IS NOT NULL AND get_quantity IS NOT NULL))
+);
+
+CREATE TABLE cart_coupons (
+    cart_id UUID REFERENCES carts(id) ON DELETE CASCADE,
+    code TEXT REFERENCES coupons(code) ON DELETE CASCADE,
+    PRIMARY KEY (cart_id, code)
+);
+
+-- +goose Down
+DROP TABLE cart_coupons;
+DROP TABLE coupons;
@@ -0,0 +1,46 @@
+-- +goose Up
+ALTER TABLE carts ADD COLUMN checked_out_at TIMESTAMPTZ;
+
+-- Orders are snapshots: they keep the cart ID without a foreign key
+-- so that deleting the cart later leaves the order untouched.
+CREATE TABLE orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cart_id UUID NOT NULL,
+    currency CHAR(3) NOT NULL,
+    subtotal BIGINT NOT NULL,
+    discount_total BIGINT NOT NULL,
+    total BIGINT NOT NULL,
+    free_shipping BOOLEAN NOT NULL,
+    discounts JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE order_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price BIGINT NOT NULL,
+    line_total BIGINT NOT NULL
+);
+
+CREATE UNIQUE INDEX orders_cart_id_key ON orders (cart_id);
+
+-- +goose StatementBegin
+CREATE FUNCTION reject_order_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'orders are immutable';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER orders_immutable BEFORE UPDATE ON orders
+    FOR EACH ROW EXECUTE FUNCTION reject_order_update();
+CREATE TRIGGER order_lines_immutable BEFORE UPDATE ON order_lines
+    FOR EACH ROW EXECUTE FUNCTION reject_order_update();
+
+-- +goose Down
+DROP TABLE order_lines;
+DROP TABLE orders;
+DROP FUNCTION reject_order_update();
+ALTER TABLE carts DROP COLUMN checked_out_at;
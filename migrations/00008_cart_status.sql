@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE carts ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'locked', 'checked_out', 'abandoned', 'expired'));
+
+CREATE TABLE cart_status_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    cart_id UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX cart_status_transitions_cart_id_idx ON cart_status_transitions (cart_id, changed_at);
+
+-- The checkout timestamp becomes the first recorded transition of checked out carts.
+-- It is also kept on the cart, next to the checked out status.
+UPDATE carts SET status = 'checked_out' WHERE checked_out_at IS NOT NULL;
+
+INSERT INTO cart_status_transitions (cart_id, from_status, to_status, changed_at)
+SELECT id, 'active', 'checked_out', checked_out_at FROM carts WHERE checked_out_at IS NOT NULL;
+
+-- +goose Down
+DROP TABLE cart_status_transitions;
+ALTER TABLE carts DROP COLUMN status;
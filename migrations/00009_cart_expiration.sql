@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE carts
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN expires_at TIMESTAMPTZ NOT NULL DEFAULT now() + INTERVAL '30 days',
+    ADD COLUMN ttl INTERVAL NOT NULL DEFAULT INTERVAL '30 days';
+
+-- The lifetime is configured in the application, which sets ttl and expires_at on creation.
+-- It is stored with the cart, so that every change extends the expiry by the same amount.
+ALTER TABLE carts ALTER COLUMN expires_at DROP DEFAULT, ALTER COLUMN ttl DROP DEFAULT;
+
+CREATE INDEX carts_status_expires_at_idx ON carts (status, expires_at);
+
+-- +goose Down
+DROP INDEX carts_status_expires_at_idx;
+ALTER TABLE carts
+    DROP COLUMN created_at,
+    DROP COLUMN updated_at,
+    DROP COLUMN expires_at,
+    DROP COLUMN ttl;
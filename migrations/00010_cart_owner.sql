@@ -0,0 +1,15 @@
+-- +goose Up
+-- Carts created before ownership was introduced keep a NULL owner and can no longer be accessed.
+ALTER TABLE carts
+    ADD COLUMN owner_kind TEXT CHECK (owner_kind IN ('user', 'session')),
+    ADD COLUMN owner_id TEXT,
+    ADD CONSTRAINT carts_owner_check CHECK ((owner_kind IS NULL) = (owner_id IS NULL));
+
+CREATE INDEX carts_owner_idx ON carts (owner_kind, owner_id);
+
+-- +goose Down
+DROP INDEX carts_owner_idx;
+ALTER TABLE carts
+    DROP CONSTRAINT carts_owner_check,
+    DROP COLUMN owner_kind,
+    DROP COLUMN owner_id;
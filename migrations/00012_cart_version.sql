@@ -0,0 +1,6 @@
+-- +goose Up
+-- The version is incremented on every change of the cart and is exposed as its ETag.
+ALTER TABLE carts ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE carts DROP COLUMN version;
@@ -0,0 +1,148 @@
+-- +goose Up
+-- The SQLite schema mirrors the Postgres one as of migration 00012.
+-- Times are written by the application as UTC text, so that they compare in chronological order.
+-- IDs default to random version 4 UUIDs, like gen_random_uuid() in Postgres.
+CREATE TABLE carts (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'locked', 'checked_out', 'abandoned', 'expired')),
+    version INTEGER NOT NULL DEFAULT 1,
+    owner_kind TEXT CHECK (owner_kind IN ('user', 'session')),
+    owner_id TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    -- The lifetime of the cart in nanoseconds.
+    ttl INTEGER NOT NULL,
+    checked_out_at TIMESTAMP,
+    CHECK ((owner_kind IS NULL) = (owner_id IS NULL))
+);
+
+CREATE INDEX carts_status_expires_at_idx ON carts (status, expires_at);
+CREATE INDEX carts_owner_idx ON carts (owner_kind, owner_id);
+
+CREATE TABLE cart_status_transitions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cart_id TEXT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX cart_status_transitions_cart_id_idx ON cart_status_transitions (cart_id, changed_at);
+
+CREATE TABLE products (
+    sku TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    unit_price INTEGER NOT NULL CHECK (unit_price >= 0),
+    currency TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE cart_items (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    cart_id TEXT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL REFERENCES products(sku),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price INTEGER NOT NULL CHECK (unit_price >= 0),
+    currency TEXT NOT NULL,
+    UNIQUE (cart_id, sku)
+);
+
+CREATE TABLE coupons (
+    code TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('percentage', 'fixed_amount', 'buy_x_get_y', 'free_shipping')),
+    percent_off INTEGER CHECK (percent_off BETWEEN 1 AND 100),
+    amount_off INTEGER CHECK (amount_off > 0),
+    currency TEXT,
+    sku TEXT REFERENCES products(sku),
+    buy_quantity INTEGER CHECK (buy_quantity > 0),
+    get_quantity INTEGER CHECK (get_quantity > 0),
+    min_subtotal INTEGER NOT NULL DEFAULT 0 CHECK (min_subtotal >= 0),
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    usage_limit INTEGER CHECK (usage_limit >= 0),
+    times_used INTEGER NOT NULL DEFAULT 0,
+    CHECK (kind <> 'percentage' OR percent_off IS NOT NULL),
+    CHECK (kind <> 'fixed_amount' OR (amount_off IS NOT NULL AND currency IS NOT NULL)),
+    CHECK (kind <> 'buy_x_get_y' OR (sku IS NOT NULL AND buy_quantity IS NOT NULL AND get_quantity IS NOT NULL))
+);
+
+CREATE TABLE cart_coupons (
+    cart_id TEXT REFERENCES carts(id) ON DELETE CASCADE,
+    code TEXT REFERENCES coupons(code) ON DELETE CASCADE,
+    PRIMARY KEY (cart_id, code)
+);
+
+-- Orders are snapshots: they keep the cart ID without a foreign key
+-- so that deleting the cart later leaves the order untouched.
+CREATE TABLE orders (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    cart_id TEXT NOT NULL UNIQUE,
+    currency TEXT NOT NULL,
+    subtotal INTEGER NOT NULL,
+    discount_total INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    free_shipping BOOLEAN NOT NULL,
+    discounts TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE order_lines (
+    id TEXT PRIMARY KEY DEFAULT (lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + abs(random()) % 4, 1) ||
+        substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6)))),
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    sku TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price INTEGER NOT NULL,
+    line_total INTEGER NOT NULL
+);
+
+-- +goose StatementBegin
+CREATE TRIGGER orders_immutable BEFORE UPDATE ON orders
+BEGIN
+    SELECT RAISE(ABORT, 'orders are immutable');
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER order_lines_immutable BEFORE UPDATE ON order_lines
+BEGIN
+    SELECT RAISE(ABORT, 'orders are immutable');
+END;
+-- +goose StatementEnd
+
+-- A row without status_code is reserved by a request that is still being processed.
+-- The reservation is a lease until locked_until, so that a retry can take over the key of a crashed request.
+CREATE TABLE idempotency_keys (
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER,
+    response_headers TEXT,
+    response_body BLOB,
+    created_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
+DROP TABLE order_lines;
+DROP TABLE orders;
+DROP TABLE cart_coupons;
+DROP TABLE coupons;
+DROP TABLE cart_items;
+DROP TABLE products;
+DROP TABLE cart_status_transitions;
+DROP TABLE carts;